@@ -2,46 +2,233 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/impersonation"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/pat"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/session"
+	"github.com/bariscan97/clean-rest-architecture/pkg/authcookie"
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"github.com/bariscan97/clean-rest-architecture/pkg/reqsign"
 	"github.com/bariscan97/clean-rest-architecture/pkg/token"
 )
 
-type authKey token.AuthKey 
+// sessionTouchInterval bounds how often a session's last-seen time is
+// written back while it is in use.
+const sessionTouchInterval = time.Minute
+
+// AuthDeps groups everything GetAuthMiddlewareFunc needs.
+type AuthDeps struct {
+	TokenMaker           *token.JWTMaker
+	Revocations          *token.RevocationList
+	PersonalAccessTokens pat.IPersonalAccessTokenRepository
+	Sessions             session.ISessionRepository
+	Cookies              *authcookie.Manager
+	Impersonations       impersonation.IImpersonationRepository
+	RequestVerifier      *reqsign.Verifier
+}
+
+func GetAuthMiddlewareFunc(deps AuthDeps) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := authenticate(w, r, deps)
+			if !ok {
+				return
+			}
+			serveAuthenticated(w, r, next, claims, deps)
+		})
+	}
+}
 
-func GetAuthMiddlewareFunc(tokenMaker *token.JWTMaker) func(http.Handler) http.Handler {
+// OptionalAuth lets anonymous requests through untouched and authenticates
+// everything else exactly like GetAuthMiddlewareFunc. Credentials that are
+// present but invalid are still rejected, so clients notice expired tokens
+// instead of silently getting the anonymous view.
+func OptionalAuth(deps AuthDeps) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			
-			claims, err := verifyClaimsFromAuthHeader(r, tokenMaker)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("error verifying token: %v", err), http.StatusUnauthorized)
+			if !hasCredentials(r, deps.Cookies) {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), authKey{}, claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			claims, ok := authenticate(w, r, deps)
+			if !ok {
+				return
+			}
+			serveAuthenticated(w, r, next, claims, deps)
 		})
 	}
 }
 
-func verifyClaimsFromAuthHeader(r *http.Request, tokenMaker *token.JWTMaker) (*token.UserClaims, error) {
+func hasCredentials(r *http.Request, cookies *authcookie.Manager) bool {
+	if r.Header.Get("Authorization") != "" {
+		return true
+	}
+	_, ok := cookies.AccessToken(r)
+	return ok
+}
+
+func serveAuthenticated(w http.ResponseWriter, r *http.Request, next http.Handler, claims *token.UserClaims, deps AuthDeps) {
+	r = r.WithContext(principal.WithPrincipal(r.Context(), principal.New(claims)))
+	if claims.IsImpersonated() {
+		auditImpersonatedRequest(w, r, next, claims, deps.Impersonations)
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+// authenticate resolves the request's credentials to claims. On failure it
+// has already written the error response and returns false.
+func authenticate(w http.ResponseWriter, r *http.Request, deps AuthDeps) (*token.UserClaims, bool) {
+	if reqsign.IsSigned(r) {
+		claims, status, err := verifySignedRequest(r, deps.RequestVerifier)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error verifying request signature: %v", err), status)
+			return nil, false
+		}
+		return claims, true
+	}
+
+	bearer, fromCookie, err := tokenFromRequest(r, deps.Cookies)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error verifying token: %v", err), http.StatusUnauthorized)
+		return nil, false
+	}
+	// Browsers attach cookies to cross-site requests on their own, so
+	// cookie authentication needs proof the caller could read ours.
+	if fromCookie && !deps.Cookies.ValidCSRF(r) {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return nil, false
+	}
+
+	if strings.HasPrefix(bearer, token.PersonalAccessTokenPrefix) {
+		claims, err := verifyPersonalAccessToken(r.Context(), bearer, deps.PersonalAccessTokens)
+		if err != nil {
+			if errors.Is(err, pat.ErrTokenNotFound) {
+				http.Error(w, "invalid personal access token", http.StatusUnauthorized)
+				return nil, false
+			}
+			http.Error(w, "error checking personal access token", http.StatusInternalServerError)
+			return nil, false
+		}
+		return claims, true
+	}
+
+	claims, err := deps.TokenMaker.VerifyToken(bearer)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error verifying token: invalid token: %v", err), http.StatusUnauthorized)
+		return nil, false
+	}
+	if claims.Purpose != "" {
+		http.Error(w, "token cannot be used for authentication", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	revoked, err := deps.Revocations.IsRevoked(r.Context(), claims)
+	if err != nil {
+		http.Error(w, "error checking token revocation", http.StatusInternalServerError)
+		return nil, false
+	}
+	if revoked {
+		http.Error(w, "token has been revoked", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	if claims.SessionID != nil {
+		active, err := deps.Sessions.TouchSession(r.Context(), *claims.SessionID, sessionTouchInterval)
+		if err != nil {
+			http.Error(w, "error checking session", http.StatusInternalServerError)
+			return nil, false
+		}
+		if !active {
+			http.Error(w, "session has been revoked", http.StatusUnauthorized)
+			return nil, false
+		}
+	}
+
+	return claims, true
+}
+
+// tokenFromRequest prefers the Authorization header and falls back to the
+// access token cookie when cookie authentication is enabled.
+func tokenFromRequest(r *http.Request, cookies *authcookie.Manager) (string, bool, error) {
+	if r.Header.Get("Authorization") == "" {
+		if t, ok := cookies.AccessToken(r); ok {
+			return t, true, nil
+		}
+	}
+
+	bearer, err := bearerFromAuthHeader(r)
+	return bearer, false, err
+}
+
+func bearerFromAuthHeader(r *http.Request) (string, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		return nil, fmt.Errorf("authorization header is missing")
+		return "", fmt.Errorf("authorization header is missing")
 	}
 
 	fields := strings.Fields(authHeader)
 	if len(fields) != 2 || fields[0] != "Bearer" {
-		return nil, fmt.Errorf("invalid authorization header")
+		return "", fmt.Errorf("invalid authorization header")
 	}
 
-	token := fields[1]
-	claims, err := tokenMaker.VerifyToken(token)
+	return fields[1], nil
+}
+
+// verifySignedRequest maps a correctly signed service request to claims for
+// the key's service principal, limited to the key's scopes and roles.
+func verifySignedRequest(r *http.Request, verifier *reqsign.Verifier) (*token.UserClaims, int, error) {
+	key, err := verifier.Verify(r)
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		switch {
+		case errors.Is(err, reqsign.ErrBodyTooLarge):
+			return nil, http.StatusRequestEntityTooLarge, err
+		case errors.Is(err, reqsign.ErrMalformed),
+			errors.Is(err, reqsign.ErrUnknownKey),
+			errors.Is(err, reqsign.ErrExpired),
+			errors.Is(err, reqsign.ErrReplayed),
+			errors.Is(err, reqsign.ErrInvalidSignature):
+			return nil, http.StatusUnauthorized, err
+		default:
+			return nil, http.StatusInternalServerError, errors.New("internal error")
+		}
 	}
 
-	return claims, nil
-}
\ No newline at end of file
+	return &token.UserClaims{
+		ID:       key.PrincipalID(),
+		UserName: "service:" + key.Service,
+		Roles:    key.Roles,
+		Scopes:   key.Scopes,
+		Service:  key.Service,
+	}, http.StatusOK, nil
+}
+
+// verifyPersonalAccessToken turns an active personal access token into
+// claims restricted to the token's scopes. Roles are deliberately left out
+// so tokens can never reach role-protected routes.
+func verifyPersonalAccessToken(ctx context.Context, bearer string, personalTokens pat.IPersonalAccessTokenRepository) (*token.UserClaims, error) {
+	t, err := personalTokens.UseToken(ctx, token.HashOpaqueToken(bearer))
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := t.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return &token.UserClaims{
+		ID:                    t.UserID,
+		Email:                 t.Email,
+		UserName:              t.UserName,
+		EmailVerified:         t.EmailVerified,
+		Scopes:                scopes,
+		PersonalAccessTokenID: &t.ID,
+	}, nil
+}
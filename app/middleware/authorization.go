@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+)
+
+// RequireRole allows the request through when the authenticated user holds
+// at least one of roles. It must be mounted after GetAuthMiddlewareFunc.
+func RequireRole(roles ...domains.Role) func(http.Handler) http.Handler {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+
+	return authorize(func(claims *token.UserClaims) bool {
+		return claims.HasRole(names...)
+	})
+}
+
+// RequirePermission allows the request through when the authenticated
+// user's roles grant every one of perms.
+func RequirePermission(perms ...domains.Permission) func(http.Handler) http.Handler {
+	return authorize(func(claims *token.UserClaims) bool {
+		for _, p := range perms {
+			if !domains.RolesHavePermission(claims.Roles, p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RequireScope rejects requests whose token was not granted every one of
+// scopes. Interactive logins are unscoped and always pass.
+func RequireScope(scopes ...domains.Scope) func(http.Handler) http.Handler {
+	return authorize(func(claims *token.UserClaims) bool {
+		for _, s := range scopes {
+			if !claims.HasScope(string(s)) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RequireVerifiedEmail rejects users who have not confirmed their email
+// address yet.
+func RequireVerifiedEmail() func(http.Handler) http.Handler {
+	return authorize(func(claims *token.UserClaims) bool {
+		return claims.EmailVerified
+	})
+}
+
+// RequireUser rejects service principals on routes that act on the caller's
+// own account or content: a service has no user row to own posts,
+// reactions or sessions. It must be mounted after authentication.
+func RequireUser() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !principal.MustFrom(r.Context()).IsUser() {
+				http.Error(w, "only user accounts can do this", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func authorize(allowed func(*token.UserClaims) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := principal.PrincipalFrom(r.Context())
+			if !ok {
+				http.Error(w, "authentication required", http.StatusUnauthorized)
+				return
+			}
+			if !allowed(p.UserClaims) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/google/uuid"
+)
+
+func TestRequireUser(t *testing.T) {
+	patID := uuid.New()
+
+	tests := []struct {
+		name   string
+		claims *token.UserClaims
+		want   int
+	}{
+		{"login", &token.UserClaims{ID: uuid.New()}, http.StatusNoContent},
+		{"personal access token", &token.UserClaims{ID: uuid.New(), PersonalAccessTokenID: &patID}, http.StatusNoContent},
+		{"signed service request", &token.UserClaims{ID: uuid.New(), Service: "billing", Scopes: []string{"posts:write"}}, http.StatusForbidden},
+		{"mounted without authentication", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+			if tt.claims != nil {
+				r = r.WithContext(principal.WithPrincipal(r.Context(), principal.New(tt.claims)))
+			}
+			w := httptest.NewRecorder()
+
+			handler := Recover()(RequireUser()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})))
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
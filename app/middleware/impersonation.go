@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/impersonation"
+	"github.com/bariscan97/clean-rest-architecture/internal/utils"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"go.uber.org/zap"
+)
+
+// DenyImpersonation blocks the route for impersonation tokens. Mount it on
+// anything that changes credentials or destroys the account.
+func DenyImpersonation() func(http.Handler) http.Handler {
+	return authorize(func(claims *token.UserClaims) bool {
+		return !claims.IsImpersonated()
+	})
+}
+
+// auditImpersonatedRequest writes an audit row before serving a request
+// made with an impersonation token and logs it, with the outcome, after.
+// Requests are refused when the audit row cannot be written.
+func auditImpersonatedRequest(w http.ResponseWriter, r *http.Request, next http.Handler, claims *token.UserClaims, audit impersonation.IImpersonationRepository) {
+	if err := audit.RecordEvent(r.Context(), &domains.ImpersonationAuditEntry{
+		TokenID: claims.RegisteredClaims.ID,
+		ActorID: claims.Actor.ID,
+		UserID:  claims.ID,
+		Event:   domains.ImpersonationEventRequest,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		IP:      utils.ClientIP(r),
+	}); err != nil {
+		zap.L().Error("error recording impersonated request", zap.Error(err))
+		http.Error(w, "error recording audit event", http.StatusInternalServerError)
+		return
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	zap.L().Warn("impersonated request",
+		zap.Bool("impersonated", true),
+		zap.String("actorID", claims.Actor.ID.String()),
+		zap.String("userID", claims.ID.String()),
+		zap.String("tokenID", claims.RegisteredClaims.ID),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Int("status", rec.status),
+	)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
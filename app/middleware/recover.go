@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"go.uber.org/zap"
+)
+
+// Recover turns a panicking handler into an error response instead of a
+// dropped connection. A handler that expected an authenticated caller but
+// found none answers 401; anything else is logged and answered 500.
+func Recover() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// Let net/http abort the response as it normally would.
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				if err, ok := rec.(error); ok && errors.Is(err, principal.ErrMissing) {
+					zap.L().Warn("route served without authentication",
+						zap.String("method", r.Method),
+						zap.String("path", r.URL.Path),
+					)
+					http.Error(w, "authentication required", http.StatusUnauthorized)
+					return
+				}
+
+				zap.L().Error("panic serving request",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("panic", fmt.Sprint(rec)),
+					zap.Stack("stack"),
+				)
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
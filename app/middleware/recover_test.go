@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/google/uuid"
+)
+
+func TestRecover(t *testing.T) {
+	mustFrom := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal.MustFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		handler       http.Handler
+		authenticated bool
+		want          int
+	}{
+		{"MustFrom behind authentication", mustFrom, true, http.StatusNoContent},
+		{"MustFrom without authentication", mustFrom, false, http.StatusUnauthorized},
+		{"wrapped ErrMissing", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(fmt.Errorf("loading profile: %w", principal.ErrMissing))
+		}), false, http.StatusUnauthorized},
+		{"other error", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(errors.New("boom"))
+		}), true, http.StatusInternalServerError},
+		{"non-error value", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}), true, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/user/sessions", nil)
+			if tt.authenticated {
+				r = r.WithContext(principal.WithPrincipal(r.Context(), principal.New(&token.UserClaims{ID: uuid.New()})))
+			}
+			w := httptest.NewRecorder()
+
+			Recover()(tt.handler).ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecoverRepanicsAbortHandler(t *testing.T) {
+	handler := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
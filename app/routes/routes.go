@@ -1,54 +1,147 @@
 package routes
 
 import (
+    "net/http"
+
     "github.com/bariscan97/clean-rest-architecture/app/middleware"
+    "github.com/bariscan97/clean-rest-architecture/internal/domains"
     "github.com/bariscan97/clean-rest-architecture/internal/handler/post"
     "github.com/bariscan97/clean-rest-architecture/internal/handler/user"
+    "github.com/bariscan97/clean-rest-architecture/pkg/config"
+    "github.com/bariscan97/clean-rest-architecture/pkg/reqsign"
     "github.com/go-chi/chi"
 )
 
 type Router struct {
     Mux         *chi.Mux
+    cfg         *config.Config
     userHandler user.Handler
     postHandler post.Handler
+
+    requestVerifier *reqsign.Verifier
 }
 
-func NewRouter(uHandler user.Handler, pHandler post.Handler) *Router {
+func NewRouter(cfg *config.Config, uHandler user.Handler, pHandler post.Handler, requestVerifier *reqsign.Verifier) *Router {
     return &Router{
-        Mux:         chi.NewRouter(),
-        userHandler: uHandler,
-        postHandler: pHandler,
+        Mux:             chi.NewRouter(),
+        cfg:             cfg,
+        userHandler:     uHandler,
+        postHandler:     pHandler,
+        requestVerifier: requestVerifier,
     }
 }
 
 func (r *Router) RegisterRoutes() {
-    tokenMaker := r.userHandler.TokenMaker
+    authDeps := middleware.AuthDeps{
+        TokenMaker:           r.userHandler.TokenMaker,
+        Revocations:          r.userHandler.Revocations,
+        PersonalAccessTokens: r.userHandler.PersonalAccessTokens,
+        Sessions:             r.userHandler.Sessions,
+        Cookies:              r.userHandler.Cookies,
+        Impersonations:       r.userHandler.Impersonations,
+        RequestVerifier:      r.requestVerifier,
+    }
+    auth := middleware.GetAuthMiddlewareFunc(authDeps)
+    optionalAuth := middleware.OptionalAuth(authDeps)
+    noImpersonation := middleware.DenyImpersonation()
+    requireUser := middleware.RequireUser()
+    postsWrite := middleware.RequireScope(domains.ScopePostsWrite)
+
+    createPost := []func(http.Handler) http.Handler{auth, requireUser, postsWrite}
+    if r.cfg.Auth.RequireVerifiedEmail {
+        createPost = append(createPost, middleware.RequireVerifiedEmail())
+    }
+
+    r.Mux.Use(middleware.Recover())
+
+    r.Mux.Get("/.well-known/jwks.json", r.userHandler.JWKS)
 
     r.Mux.Route("/api/v1", func(api chi.Router) {
 
+        api.Route("/tags", func(tr chi.Router) {
+            tr.Get("/", r.postHandler.SearchTags)
+            tr.With(optionalAuth).Get("/{tag}/posts", r.postHandler.ListTagPosts)
+        })
+
         api.Route("/posts", func(pr chi.Router) {
-            pr.With(middleware.GetAuthMiddlewareFunc(tokenMaker)).Post("/", r.postHandler.CreatePost)
-			pr.Get("/", r.postHandler.ListPosts)
+            pr.With(createPost...).Post("/", r.postHandler.CreatePost)
+			pr.With(optionalAuth).Get("/", r.postHandler.ListPosts)
+            pr.With(optionalAuth).Get("/search", r.postHandler.SearchPosts)
 			pr.Route("/{id}", func(idr chi.Router) {
-                idr.Get("/comments", r.postHandler.GetCommentByPostID)
+                idr.With(optionalAuth).Get("/comments", r.postHandler.GetCommentByPostID)
+                idr.With(optionalAuth).Get("/thread", r.postHandler.GetThread)
+                idr.Get("/reactions/{kind}", r.postHandler.ListReactions)
 
                 idr.Group(func(gr chi.Router) {
-                    gr.Use(middleware.GetAuthMiddlewareFunc(tokenMaker))
+                    gr.Use(auth, requireUser, postsWrite)
                     gr.Patch("/", r.postHandler.UpdatePost)
                     gr.Delete("/", r.postHandler.DeletePostByID)
+                    gr.Put("/reactions/{kind}", r.postHandler.AddReaction)
+                    gr.Delete("/reactions/{kind}", r.postHandler.RemoveReaction)
                 })
             })
         })
 
         api.Route("/user", func(u chi.Router) {
-            u.Patch("/", r.userHandler.UpdateUser)
-            u.Delete("/", r.userHandler.DeleteUser)
+            u.With(auth, requireUser, noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Patch("/", r.userHandler.UpdateUser)
+            u.With(auth, requireUser, noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Delete("/", r.userHandler.DeleteUser)
             u.Get("/{id}", r.userHandler.GetUserByID)
+
+            u.Route("/export", func(e chi.Router) {
+                // The signed link is the credential for downloads.
+                e.Get("/{id}/download", r.userHandler.DownloadDataExport)
+
+                e.Group(func(g chi.Router) {
+                    g.Use(auth, requireUser, noImpersonation)
+                    g.With(middleware.RequireScope(domains.ScopeUserRead)).Get("/", r.userHandler.GetDataExport)
+                    g.With(middleware.RequireScope(domains.ScopeUserWrite)).Post("/", r.userHandler.RequestDataExport)
+                })
+            })
+
+            u.Route("/mfa", func(m chi.Router) {
+                m.Use(auth, requireUser, noImpersonation, middleware.RequireScope(domains.ScopeUserWrite))
+                m.Post("/enroll", r.userHandler.EnrollMFA)
+                m.Post("/confirm", r.userHandler.ConfirmMFA)
+            })
+
+            u.Route("/sessions", func(s chi.Router) {
+                s.Use(auth, requireUser)
+                s.With(middleware.RequireScope(domains.ScopeUserRead)).Get("/", r.userHandler.ListSessions)
+                s.With(noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Delete("/", r.userHandler.RevokeOtherSessions)
+                s.With(noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Delete("/{id}", r.userHandler.RevokeSession)
+            })
+
+            u.Route("/tokens", func(t chi.Router) {
+                t.Use(auth, requireUser)
+                t.With(middleware.RequireScope(domains.ScopeUserRead)).Get("/", r.userHandler.ListPersonalAccessTokens)
+                t.With(noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Post("/", r.userHandler.CreatePersonalAccessToken)
+                t.With(noImpersonation, middleware.RequireScope(domains.ScopeUserWrite)).Delete("/{id}", r.userHandler.RevokePersonalAccessToken)
+            })
+        })
+
+        api.Route("/admin", func(ad chi.Router) {
+            ad.Use(auth, noImpersonation, middleware.RequirePermission(domains.PermissionManageUsers))
+            ad.Get("/users", r.userHandler.ListUsers)
+            ad.Delete("/users/{id}", r.userHandler.AdminDeleteUser)
+            ad.Put("/users/{id}/roles", r.userHandler.UpdateUserRoles)
+            ad.Delete("/users/{id}/lockout", r.userHandler.UnlockUser)
+            ad.Put("/users/{id}/suspension", r.userHandler.SuspendUser)
+            ad.Delete("/users/{id}/suspension", r.userHandler.UnsuspendUser)
+            ad.With(middleware.RequirePermission(domains.PermissionImpersonate)).Post("/users/{id}/impersonate", r.userHandler.ImpersonateUser)
         })
 
         api.Route("/auth", func(a chi.Router) {
             a.Post("/register", r.userHandler.CreateUser)
             a.Post("/login", r.userHandler.LoginUser)
+            a.Post("/refresh", r.userHandler.RefreshToken)
+            a.With(auth).Post("/logout", r.userHandler.Logout)
+            a.Get("/verify-email", r.userHandler.VerifyEmail)
+            a.With(auth).Post("/verify-email/resend", r.userHandler.ResendVerificationEmail)
+            a.Post("/password/forgot", r.userHandler.ForgotPassword)
+            a.Post("/password/reset", r.userHandler.ResetPassword)
+            a.Post("/mfa/verify", r.userHandler.VerifyMFA)
+            a.Get("/oidc/{provider}", r.userHandler.StartOIDCLogin)
+            a.Get("/oidc/{provider}/callback", r.userHandler.OIDCCallback)
         })
     })
 }
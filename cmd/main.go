@@ -2,29 +2,59 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/bariscan97/clean-rest-architecture/app/routes"
+	"github.com/bariscan97/clean-rest-architecture/internal/exporter"
 	post_handler "github.com/bariscan97/clean-rest-architecture/internal/handler/post"
 	user_handler "github.com/bariscan97/clean-rest-architecture/internal/handler/user"
+	dataexport_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/dataexport"
+	identity_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/identity"
+	impersonation_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/impersonation"
+	loginattempt_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/loginattempt"
+	mfa_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/mfa"
+	nonce_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/nonce"
+	passwordreset_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/passwordreset"
+	pat_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/pat"
 	post_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/post"
+	refresh_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/refresh"
+	revocation_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/revocation"
+	session_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/session"
 	user_repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
+	"github.com/bariscan97/clean-rest-architecture/pkg/authcookie"
+	"github.com/bariscan97/clean-rest-architecture/pkg/blobstore"
 	"github.com/bariscan97/clean-rest-architecture/pkg/config"
 	"github.com/bariscan97/clean-rest-architecture/pkg/database"
+	"github.com/bariscan97/clean-rest-architecture/pkg/mailer"
+	"github.com/bariscan97/clean-rest-architecture/pkg/oidc"
+	"github.com/bariscan97/clean-rest-architecture/pkg/password"
+	"github.com/bariscan97/clean-rest-architecture/pkg/reqsign"
+	"github.com/bariscan97/clean-rest-architecture/pkg/signedurl"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
 	"github.com/ianschenck/envflag"
 	"go.uber.org/zap"
 )
 
-const minSecretKeySize = 32
+const (
+	minSecretKeySize        = 32
+	revocationSweepInterval = time.Minute
+	nonceSweepInterval      = time.Minute
+	accountPurgeInterval    = time.Hour
+	exportPollInterval      = 10 * time.Second
+)
 
 var (
 	addr string
 	secretKey *string
+	signingKeyFile *string
+	retiredKeyFiles *string
 )
 
 
@@ -46,29 +76,159 @@ func gracefulShutdown(server *http.Server) {
 	zap.L().Info("Server gracefully stopped")
 }
 
+// newTokenMaker signs with JWT_SIGNING_KEY_FILE when set and falls back to
+// HS256 with SECRET_KEY otherwise.
+func newTokenMaker() *token.JWTMaker {
+	if *signingKeyFile == "" {
+		if len(*secretKey) < minSecretKeySize {
+			zap.L().Fatal("SECRET_KEY must be at least X characters",
+				zap.Int("minSecretKeySize", minSecretKeySize),
+				zap.Int("gotLength", len(*secretKey)),
+			)
+		}
+		return token.NewJWTMaker(*secretKey)
+	}
+
+	current, err := token.LoadSignerFromPEM(*signingKeyFile)
+	if err != nil {
+		zap.L().Fatal("Error loading JWT signing key", zap.Error(err))
+	}
+
+	var retired []token.Signer
+	for _, path := range strings.Split(*retiredKeyFiles, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		signer, err := token.LoadSignerFromPEM(path)
+		if err != nil {
+			zap.L().Fatal("Error loading retired JWT key", zap.Error(err))
+		}
+		retired = append(retired, signer)
+	}
+
+	keyring, err := token.NewKeyring(current, retired...)
+	if err != nil {
+		zap.L().Fatal("Error building JWT keyring", zap.Error(err))
+	}
+
+	zap.L().Info("JWT signing key loaded",
+		zap.String("kid", current.KeyID()),
+		zap.String("alg", current.Method().Alg()),
+		zap.Int("retiredKeys", len(retired)),
+	)
+
+	return token.NewJWTMakerWithKeyring(keyring)
+}
+
+// newExportLinkSigner signs download links with export.link_secret. Without
+// one a random key is used, so links stop working after a restart and are
+// not shared between instances.
+func newExportLinkSigner(cfg *config.Config) *signedurl.Signer {
+	if cfg.Export.LinkSecret != "" {
+		return signedurl.NewSigner([]byte(cfg.Export.LinkSecret))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		zap.L().Fatal("Error generating export link secret", zap.Error(err))
+	}
+	zap.L().Warn("export.link_secret is not set, using a random per-process key")
+	return signedurl.NewSigner(secret)
+}
+
 func main() {
 	cfg, _ := config.LoadConfig("*")
 	db := database.NewConnection(cfg)
 
 	addr = strconv.Itoa(cfg.App.Port)
 	secretKey = envflag.String("SECRET_KEY", "01234567890123456789012345678901", "secret key for JWT signing")
-	
-	if len(*secretKey) < minSecretKeySize {
-		zap.L().Fatal("SECRET_KEY must be at least X characters",
-            zap.Int("minSecretKeySize", minSecretKeySize),
-            zap.Int("gotLength", len(*secretKey)),
-        )
-	}
+	signingKeyFile = envflag.String("JWT_SIGNING_KEY_FILE", "", "PEM encoded RSA or Ed25519 private key for JWT signing")
+	retiredKeyFiles = envflag.String("JWT_RETIRED_KEY_FILES", "", "comma separated PEM keys still accepted for verification")
+	envflag.Parse()
+
+	tokenMaker := newTokenMaker()
 
 	userRepo := user_repo.NewUserRepository(db)
 	postRepo := post_repo.NewUserRepository(db)
+	refreshRepo := refresh_repo.NewRefreshTokenRepository(db)
+	patRepo := pat_repo.NewPersonalAccessTokenRepository(db)
+	passwordResetRepo := passwordreset_repo.NewPasswordResetRepository(db)
+	mfaRepo := mfa_repo.NewMFARepository(db)
+	loginAttemptRepo := loginattempt_repo.NewLoginAttemptRepository(db)
+	sessionRepo := session_repo.NewSessionRepository(db)
+	identityRepo := identity_repo.NewIdentityRepository(db)
+	impersonationRepo := impersonation_repo.NewImpersonationRepository(db)
+	exportRepo := dataexport_repo.NewDataExportRepository(db)
+	revocations := token.NewRevocationList(revocation_repo.NewRevocationRepository(db))
+
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go revocations.StartSweeper(sweepCtx, revocationSweepInterval)
+
+	requestVerifier, err := reqsign.NewVerifier(cfg, nonce_repo.NewNonceRepository(db))
+	if err != nil {
+		zap.L().Fatal("Error loading request signing keys", zap.Error(err))
+	}
+	go requestVerifier.StartSweeper(sweepCtx, nonceSweepInterval)
+
+	mail, err := mailer.NewMailer(cfg)
+	if err != nil {
+		zap.L().Fatal("Error creating mailer", zap.Error(err))
+	}
+
+	passwords, err := password.NewHasher(cfg)
+	if err != nil {
+		zap.L().Fatal("Error creating password hasher", zap.Error(err))
+	}
+
+	passwordPolicy, err := password.NewPolicy(cfg)
+	if err != nil {
+		zap.L().Fatal("Error loading password policy", zap.Error(err))
+	}
+
+	oidcProviders, err := oidc.NewRegistry(cfg)
+	if err != nil {
+		zap.L().Fatal("Error configuring OIDC providers", zap.Error(err))
+	}
+
+	blobs, err := blobstore.NewStore(cfg)
+	if err != nil {
+		zap.L().Fatal("Error creating blob store", zap.Error(err))
+	}
+	go exporter.NewWorker(exportRepo, blobs, cfg.Export.Retention).Start(sweepCtx, exportPollInterval)
+
+	userHandler := user_handler.NewUserHandler(user_handler.Deps{
+		Config:               cfg,
+		Users:                userRepo,
+		RefreshTokens:        refreshRepo,
+		PersonalAccessTokens: patRepo,
+		Sessions:             sessionRepo,
+		PasswordResets:       passwordResetRepo,
+		MFA:                  mfaRepo,
+		LoginAttempts:        loginAttemptRepo,
+		Passwords:            passwords,
+		PasswordPolicy:       passwordPolicy,
+		Identities:           identityRepo,
+		OIDCProviders:        oidcProviders,
+		Exports:              exportRepo,
+		Blobs:                blobs,
+		ExportLinks:          newExportLinkSigner(cfg),
+		Revocations:          revocations,
+		TokenMaker:           tokenMaker,
+		Mailer:               mail,
+		Cookies:              authcookie.NewManager(cfg),
+		Impersonations:       impersonationRepo,
+	})
+	go userHandler.StartAccountPurger(sweepCtx, accountPurgeInterval)
 
-	userHandler := user_handler.NewUserHandler(userRepo, *secretKey)
 	postHandler := post_handler.NewPostHandler(postRepo)
 
 	r := routes.NewRouter(
+		cfg,
 		*userHandler,
 		*postHandler,
+		requestVerifier,
 	)
 	r.RegisterRoutes()
 
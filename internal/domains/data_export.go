@@ -0,0 +1,41 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is one request for a copy of everything stored about a user.
+// The finished archive lives in the blob store under BlobKey until
+// ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      string
+	BlobKey     *string
+	Error       string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+	CreateAt    time.Time
+}
+
+// IsDownloadable reports whether the archive exists and has not expired.
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportReady && e.BlobKey != nil && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// ExportTable is one dataset of an export, written out as both JSON and CSV.
+type ExportTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
@@ -0,0 +1,30 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an account at an external OIDC provider.
+// Subject is the provider's stable user ID, not the email.
+type UserIdentity struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Provider    string
+	Subject     string
+	Email       string
+	LastLoginAt *time.Time
+	CreateAt    time.Time
+}
+
+// OIDCLoginState is kept between redirecting to a provider and handling its
+// callback. Only the hash of the state parameter is stored.
+type OIDCLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreateAt     time.Time
+}
@@ -0,0 +1,28 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ImpersonationEventStart   = "start"
+	ImpersonationEventRequest = "request"
+)
+
+// ImpersonationAuditEntry records an admin starting to impersonate a user
+// or making a request with an impersonation token. TokenID is the token's
+// jti and ties the request rows to their start row.
+type ImpersonationAuditEntry struct {
+	ID       uuid.UUID
+	TokenID  string
+	ActorID  uuid.UUID
+	UserID   uuid.UUID
+	Event    string
+	Method   string
+	Path     string
+	IP       string
+	Reason   string
+	CreateAt time.Time
+}
@@ -0,0 +1,19 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LockoutEvent is recorded whenever an account or client IP crosses the
+// failed-login threshold.
+type LockoutEvent struct {
+	ID          uuid.UUID
+	Key         string
+	UserID      *uuid.UUID
+	IP          string
+	Failures    int
+	LockedUntil time.Time
+	CreateAt    time.Time
+}
@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFA is a user's TOTP enrollment. It only protects logins once ConfirmedAt
+// is set.
+type MFA struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep *int64
+	CreateAt     time.Time
+}
@@ -0,0 +1,22 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a list ordered by creation time, with the ID
+// breaking ties.
+type Cursor struct {
+	CreateAt time.Time
+	ID       uuid.UUID
+}
+
+// PageRequest asks for up to Limit items following Cursor in list order, or
+// preceding it when Backward is set. A nil Cursor starts at the beginning.
+type PageRequest struct {
+	Cursor   *Cursor
+	Backward bool
+	Limit    int
+}
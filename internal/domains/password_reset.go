@@ -0,0 +1,16 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreateAt  time.Time
+}
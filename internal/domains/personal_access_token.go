@@ -0,0 +1,28 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreateAt   time.Time
+}
+
+// PersonalAccessTokenOwner is a token looked up for authentication together
+// with the user it belongs to.
+type PersonalAccessTokenOwner struct {
+	PersonalAccessToken
+	UserName      string
+	Email         string
+	EmailVerified bool
+}
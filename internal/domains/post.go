@@ -12,10 +12,19 @@ type Post struct {
 	ParentID *uuid.UUID
 	Title    string
 	Content  string
+	Tags     []PostTag
 	UpdateAt time.Time
 	CreateAt time.Time
 }
 
+// PostUpdate holds the fields of a post edit that were given. A nil Tags
+// keeps the explicit tags as they are.
+type PostUpdate struct {
+	Title   *string
+	Content *string
+	Tags    *[]string
+}
+
 type PostManyToMany struct {
 	ID       uuid.UUID
 	UserID   uuid.UUID
@@ -24,6 +33,50 @@ type PostManyToMany struct {
 	Title    string
 	Content  string
 	UserImg  *string
-	UpdateAt time.Time
-	CreateAt time.Time
+	Tags     []string
+	// Reactions counts reactions by kind. ViewerReactions is only loaded
+	// for authenticated requests.
+	Reactions       map[string]int
+	ViewerReactions []string
+	UpdateAt        time.Time
+	CreateAt        time.Time
+}
+
+// ThreadPost is one post of a comment thread. Depth is 0 for the post the
+// thread was requested for. Position counts siblings from 1; a post whose
+// Position exceeds the per-level limit was only loaded to tell that its
+// parent has more replies.
+type ThreadPost struct {
+	PostManyToMany
+	Depth      int
+	Position   int
+	ReplyCount int
 }
+
+// PostSearch narrows a full-text search. Query uses websearch_to_tsquery
+// syntax; the other filters are optional and To is exclusive.
+type PostSearch struct {
+	Query  string
+	UserID *uuid.UUID
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// PostSearchResult is a matching post with its rank. The highlights are the
+// title and an excerpt of the content with matches wrapped in
+// HighlightStart and HighlightStop.
+type PostSearchResult struct {
+	PostManyToMany
+	Rank             float64
+	TitleHighlight   string
+	ContentHighlight string
+}
+
+// Matches in search highlights are delimited with control characters rather
+// than markup so the text can be escaped before the markers are rendered.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
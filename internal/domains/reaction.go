@@ -0,0 +1,46 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+// ReactionKinds must match the check constraint on post_reactions.kind.
+var ReactionKinds = []string{
+	ReactionLike,
+	ReactionLove,
+	ReactionLaugh,
+	ReactionWow,
+	ReactionSad,
+	ReactionAngry,
+}
+
+func IsValidReactionKind(kind string) bool {
+	for _, k := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction is one user's reaction to a post, with the profile fields shown
+// when listing who reacted.
+type Reaction struct {
+	PostID   uuid.UUID
+	UserID   uuid.UUID
+	UserName string
+	UserImg  *string
+	Kind     string
+	CreateAt time.Time
+}
@@ -0,0 +1,20 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single link in a rotation chain. Every token issued from
+// the same login shares a FamilyID so the whole chain can be revoked at once.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreateAt  time.Time
+}
@@ -0,0 +1,47 @@
+package domains
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionDeleteAnyPost Permission = "posts:delete:any"
+	PermissionManageUsers   Permission = "users:manage"
+	PermissionImpersonate   Permission = "users:impersonate"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PermissionDeleteAnyPost},
+	RoleAdmin:     {PermissionDeleteAnyPost, PermissionManageUsers, PermissionImpersonate},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[Role(role)]
+	return ok
+}
+
+func (r Role) HasPermission(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesHavePermission reports whether any of roles grants p.
+func RolesHavePermission(roles []string, p Permission) bool {
+	for _, role := range roles {
+		if Role(role).HasPermission(p) {
+			return true
+		}
+	}
+	return false
+}
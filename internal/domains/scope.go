@@ -0,0 +1,22 @@
+package domains
+
+// Scope limits what a personal access token may do. Interactive logins are
+// not scoped.
+type Scope string
+
+const (
+	ScopePostsWrite Scope = "posts:write"
+	ScopeUserRead   Scope = "user:read"
+	ScopeUserWrite  Scope = "user:write"
+)
+
+var scopes = map[Scope]struct{}{
+	ScopePostsWrite: {},
+	ScopeUserRead:   {},
+	ScopeUserWrite:  {},
+}
+
+func IsValidScope(scope string) bool {
+	_, ok := scopes[Scope(scope)]
+	return ok
+}
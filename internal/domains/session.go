@@ -0,0 +1,19 @@
+package domains
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a single login on one device. Refresh tokens issued for it use
+// the session ID as their FamilyID.
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	UserAgent  string
+	IP         string
+	CreateAt   time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,108 @@
+package domains
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxPostTags  = 10
+	MaxTagLength = 50
+)
+
+var (
+	ErrInvalidTag  = errors.New("tags may only contain letters, digits and underscores, with at least one letter")
+	ErrTagTooLong  = errors.New("tag is too long")
+	ErrTooManyTags = errors.New("too many tags")
+)
+
+// Tag is a normalised tag. PostCount is the number of posts with the tag
+// whose authors are neither suspended nor waiting to be purged.
+type Tag struct {
+	ID        uuid.UUID
+	Name      string
+	PostCount int
+	CreateAt  time.Time
+}
+
+// PostTag is a tag on a post. Explicit tags were given by the author; the
+// others were taken from #hashtags in the content and follow it on edits.
+type PostTag struct {
+	Name     string
+	Explicit bool
+}
+
+// A hashtag has to start a word, so URL fragments and "C#" are skipped.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]+)`)
+
+// NormalizeTag lower-cases s and drops a leading '#'.
+func NormalizeTag(s string) (string, error) {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return "", ErrTagTooLong
+	}
+
+	hasLetter := false
+	for _, c := range name {
+		switch {
+		case unicode.IsLetter(c):
+			hasLetter = true
+		case unicode.IsDigit(c) || c == '_':
+		default:
+			return "", ErrInvalidTag
+		}
+	}
+	if !hasLetter {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// ExtractHashtags returns the normalised #hashtags in content in order of
+// appearance, skipping ones that are not valid tags.
+func ExtractHashtags(content string) []string {
+	var tags []string
+	for _, m := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		if name, err := NormalizeTag(m[1]); err == nil {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
+// MergePostTags combines already normalised explicit tags with the
+// hashtags in content, dropping duplicates. More than MaxPostTags explicit
+// tags is an error; hashtags only fill the slots that are left.
+func MergePostTags(explicit []string, content string) ([]PostTag, error) {
+	seen := make(map[string]bool)
+	var tags []PostTag
+
+	for _, name := range explicit {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, PostTag{Name: name, Explicit: true})
+	}
+	if len(tags) > MaxPostTags {
+		return nil, ErrTooManyTags
+	}
+
+	for _, name := range ExtractHashtags(content) {
+		if len(tags) == MaxPostTags {
+			break
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, PostTag{Name: name})
+	}
+	return tags, nil
+}
@@ -0,0 +1,154 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"golang", "golang", nil},
+		{"#GoLang", "golang", nil},
+		{"  #go_1  ", "go_1", nil},
+		{"Çay", "çay", nil},
+		{"日本語", "日本語", nil},
+		{strings.Repeat("a", MaxTagLength), strings.Repeat("a", MaxTagLength), nil},
+		{strings.Repeat("ü", MaxTagLength), strings.Repeat("ü", MaxTagLength), nil},
+		{strings.Repeat("a", MaxTagLength+1), "", ErrTagTooLong},
+		{"", "", ErrInvalidTag},
+		{"#", "", ErrInvalidTag},
+		{"2024", "", ErrInvalidTag},
+		{"___", "", ErrInvalidTag},
+		{"go-lang", "", ErrInvalidTag},
+		{"go lang", "", ErrInvalidTag},
+		{"##go", "", ErrInvalidTag},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.in)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "no tags here", nil},
+		{"start of content", "#go is fun", []string{"go"}},
+		{"in order", "learning #Go and #rust, then #zig.", []string{"go", "rust", "zig"}},
+		{"after punctuation", "(#go) \"#rust\" ¿#zig?", []string{"go", "rust", "zig"}},
+		{"after newline", "line one\n#go", []string{"go"}},
+		{"unicode", "çok güzel #çay #日本", []string{"çay", "日本"}},
+		{"duplicates kept", "#go #Go", []string{"go", "go"}},
+		{"mid word", "C# and email#tag", nil},
+		{"url fragment", "see https://example.com/page#section", nil},
+		{"html entity", "fish &#38; chips", nil},
+		{"double hash", "##go", nil},
+		{"glued hashtags", "#go#rust", []string{"go"}},
+		{"digits only", "issue #123 fixed", nil},
+		{"too long", "#" + strings.Repeat("a", MaxTagLength+1), nil},
+		{"hyphen ends tag", "#go-lang", []string{"go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractHashtags(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractHashtags(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePostTags(t *testing.T) {
+	explicit := func(names ...string) []PostTag {
+		tags := make([]PostTag, len(names))
+		for i, name := range names {
+			tags[i] = PostTag{Name: name, Explicit: true}
+		}
+		return tags
+	}
+	numbered := func(prefix string, n int) []string {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return names
+	}
+	hashtags := func(names []string) string {
+		return "#" + strings.Join(names, " #")
+	}
+
+	tests := []struct {
+		name     string
+		explicit []string
+		content  string
+		want     []PostTag
+		wantErr  error
+	}{
+		{"nothing", nil, "plain text", nil, nil},
+		{"explicit only", []string{"go", "rust"}, "", explicit("go", "rust"), nil},
+		{"hashtags only", nil, "#go and #rust", []PostTag{{Name: "go"}, {Name: "rust"}}, nil},
+		{
+			"explicit first",
+			[]string{"rust"}, "#go",
+			[]PostTag{{Name: "rust", Explicit: true}, {Name: "go"}},
+			nil,
+		},
+		{"duplicate explicit", []string{"go", "go", "rust"}, "", explicit("go", "rust"), nil},
+		{"duplicate hashtags", nil, "#go #Go #go", []PostTag{{Name: "go"}}, nil},
+		{"hashtag repeats explicit", []string{"go"}, "#go", explicit("go"), nil},
+		{"max explicit", numbered("t", MaxPostTags), "", explicit(numbered("t", MaxPostTags)...), nil},
+		{
+			"duplicates do not count towards the limit",
+			append(numbered("t", MaxPostTags), "t0"), "",
+			explicit(numbered("t", MaxPostTags)...),
+			nil,
+		},
+		{"too many explicit", numbered("t", MaxPostTags+1), "", nil, ErrTooManyTags},
+		{
+			"hashtags fill the remaining slots",
+			numbered("t", MaxPostTags-2), hashtags(numbered("h", 5)),
+			append(explicit(numbered("t", MaxPostTags-2)...), PostTag{Name: "h0"}, PostTag{Name: "h1"}),
+			nil,
+		},
+		{
+			"hashtags alone are capped",
+			nil, hashtags(numbered("h", MaxPostTags+3)),
+			func() []PostTag {
+				var tags []PostTag
+				for _, name := range numbered("h", MaxPostTags) {
+					tags = append(tags, PostTag{Name: name})
+				}
+				return tags
+			}(),
+			nil,
+		},
+		{
+			"full explicit set ignores hashtags",
+			numbered("t", MaxPostTags), "#extra",
+			explicit(numbered("t", MaxPostTags)...),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePostTags(tt.explicit, tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MergePostTags error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergePostTags = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,15 +7,42 @@ import (
 )
 
 type User struct {
-	ID       uuid.UUID
-	UserName string
-	Email    string
-	Password string
-	ImgUrl   string
-	UpdateAt *time.Time
-	CreateAt time.Time
+	ID              uuid.UUID
+	UserName        string
+	Email           string
+	Password        string
+	ImgUrl          string
+	Roles           []string
+	EmailVerifiedAt *time.Time
+	// DeactivatedAt is set while the account waits to be purged at
+	// PurgeAfter. Logging in before then cancels the deletion.
+	DeactivatedAt    *time.Time
+	PurgeAfter       *time.Time
+	SuspendedAt      *time.Time
+	SuspensionReason *string
+	UpdateAt         *time.Time
+	CreateAt         time.Time
 }
 
+const (
+	UserStatusActive          = "active"
+	UserStatusPendingDeletion = "pending_deletion"
+	UserStatusSuspended       = "suspended"
+)
 
+// Status reports the account state. Suspension wins over a pending
+// deletion because it is the one an administrator has to lift.
+func (u *User) Status() string {
+	switch {
+	case u.SuspendedAt != nil:
+		return UserStatusSuspended
+	case u.DeactivatedAt != nil:
+		return UserStatusPendingDeletion
+	default:
+		return UserStatusActive
+	}
+}
 
-
+func (u *User) IsActive() bool {
+	return u.Status() == UserStatusActive
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+)
+
+type manifest struct {
+	ExportID    string    `json:"export_id"`
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// writeArchive writes every table as <name>.json and <name>.csv plus a
+// manifest.json describing the archive.
+func writeArchive(w io.Writer, export *domains.DataExport, generatedAt time.Time, tables []*domains.ExportTable) error {
+	zw := zip.NewWriter(w)
+
+	m := manifest{
+		ExportID:    export.ID.String(),
+		UserID:      export.UserID.String(),
+		GeneratedAt: generatedAt,
+	}
+	for _, table := range tables {
+		jsonName, csvName := table.Name+".json", table.Name+".csv"
+		if err := writeFile(zw, jsonName, generatedAt, func(f io.Writer) error { return writeJSON(f, table) }); err != nil {
+			return err
+		}
+		if err := writeFile(zw, csvName, generatedAt, func(f io.Writer) error { return writeCSV(f, table) }); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, jsonName, csvName)
+	}
+
+	if err := writeFile(zw, "manifest.json", generatedAt, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeFile(zw *zip.Writer, name string, modified time.Time, write func(io.Writer) error) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("error adding %s to archive: %w", name, err)
+	}
+	if err := write(f); err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, table *domains.ExportTable) error {
+	records := make([]map[string]any, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		record := make(map[string]any, len(table.Columns))
+		for i, col := range table.Columns {
+			record[col] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeCSV(w io.Writer, table *domains.ExportTable) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(table.Columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			record[i] = csvValue(value)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return neutralizeFormula(strings.Join(parts, ";"))
+	case string:
+		return neutralizeFormula(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// neutralizeFormula stops spreadsheet applications from evaluating user
+// content such as post bodies as formulas when the CSV is opened.
+func neutralizeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
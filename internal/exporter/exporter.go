@@ -0,0 +1,122 @@
+// Package exporter builds the personal data archives users request through
+// /api/v1/user/export. Requests are queued in the database and processed
+// in the background so a large account never ties up an HTTP request.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/dataexport"
+	"github.com/bariscan97/clean-rest-architecture/pkg/blobstore"
+	"go.uber.org/zap"
+)
+
+// staleAfter is how long an export may stay running before another worker
+// assumes the first one died and starts over.
+const staleAfter = 15 * time.Minute
+
+type Worker struct {
+	exports   dataexport.IDataExportRepository
+	blobs     blobstore.Store
+	retention time.Duration
+}
+
+// NewWorker returns a worker that keeps finished archives for retention.
+func NewWorker(exports dataexport.IDataExportRepository, blobs blobstore.Store, retention time.Duration) *Worker {
+	return &Worker{exports: exports, blobs: blobs, retention: retention}
+}
+
+// Start processes queued exports and removes expired archives every
+// interval until ctx is cancelled.
+func (w *Worker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processPending(ctx)
+			if err := w.deleteExpired(ctx); err != nil {
+				zap.L().Error("error deleting expired data exports", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (w *Worker) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := w.exports.ClaimExport(ctx, staleAfter)
+		if err != nil {
+			if !errors.Is(err, dataexport.ErrNoPendingExport) {
+				zap.L().Error("error claiming data export", zap.Error(err))
+			}
+			return
+		}
+
+		if err := w.build(ctx, export); err != nil {
+			zap.L().Error("error building data export",
+				zap.String("exportID", export.ID.String()),
+				zap.String("userID", export.UserID.String()),
+				zap.Error(err),
+			)
+			if err := w.exports.FailExport(ctx, export.ID, "the export could not be created"); err != nil {
+				zap.L().Error("error marking data export failed", zap.Error(err))
+			}
+			continue
+		}
+
+		zap.L().Info("data export ready",
+			zap.String("exportID", export.ID.String()),
+			zap.String("userID", export.UserID.String()),
+		)
+	}
+}
+
+func (w *Worker) build(ctx context.Context, export *domains.DataExport) error {
+	tables, err := w.exports.CollectUserData(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	key := blobKey(export)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, export, time.Now().UTC(), tables))
+	}()
+	if err := w.blobs.Put(ctx, key, pr); err != nil {
+		pr.CloseWithError(err)
+		return err
+	}
+
+	if err := w.exports.CompleteExport(ctx, export.ID, key, time.Now().Add(w.retention)); err != nil {
+		if delErr := w.blobs.Delete(ctx, key); delErr != nil {
+			zap.L().Error("error deleting orphaned data export", zap.String("key", key), zap.Error(delErr))
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *Worker) deleteExpired(ctx context.Context) error {
+	keys, err := w.exports.DeleteExpiredExports(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := w.blobs.Delete(ctx, key); err != nil {
+			zap.L().Error("error deleting expired data export", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func blobKey(export *domains.DataExport) string {
+	return fmt.Sprintf("exports/%s/%s.zip", export.UserID, export.ID)
+}
@@ -0,0 +1,148 @@
+// Package pagination holds what list endpoints share for keyset paging:
+// opaque cursors, the limit parameter, the response envelope and RFC 8288
+// Link headers.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+
+	forward  = "n"
+	backward = "p"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is the envelope cursor-paginated endpoints respond with.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// EncodeCursor turns a position into the opaque string handed to clients.
+// backward cursors continue towards the start of the list.
+func EncodeCursor(c domains.Cursor, backwardCursor bool) string {
+	dir := forward
+	if backwardCursor {
+		dir = backward
+	}
+	raw := dir + "|" + c.CreateAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reverses EncodeCursor.
+func DecodeCursor(s string) (*domains.Cursor, bool, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || (parts[0] != forward && parts[0] != backward) {
+		return nil, false, ErrInvalidCursor
+	}
+	createAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	return &domains.Cursor{CreateAt: createAt, ID: id}, parts[0] == backward, nil
+}
+
+// ParseLimit reads ?limit=, falling back to DefaultLimit for missing or
+// invalid values and capping it at MaxLimit.
+func ParseLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// ParseRequest builds a page request from ?cursor= and ?limit=.
+func ParseRequest(r *http.Request) (domains.PageRequest, error) {
+	req := domains.PageRequest{Limit: ParseLimit(r)}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		cursor, backwardCursor, err := DecodeCursor(s)
+		if err != nil {
+			return req, err
+		}
+		req.Cursor, req.Backward = cursor, backwardCursor
+	}
+	return req, nil
+}
+
+// WantsCursor reports whether the client opted into cursor pagination by
+// sending ?cursor=, left empty for the first page. Every other request
+// keeps the old offset-based bare array response.
+func WantsCursor(r *http.Request) bool {
+	return r.URL.Query().Has("cursor")
+}
+
+// NewPage wraps data with cursors to its neighbours. cursorAt returns the
+// position of data[i]; hasMore tells whether the repository saw further
+// items in the direction req went.
+func NewPage[T any](data []T, req domains.PageRequest, hasMore bool, cursorAt func(i int) domains.Cursor) Page[T] {
+	page := Page[T]{Data: data}
+	if len(data) == 0 {
+		page.Data = []T{}
+		return page
+	}
+	first, last := cursorAt(0), cursorAt(len(data)-1)
+
+	if req.Backward {
+		if hasMore {
+			page.PrevCursor = EncodeCursor(first, true)
+		}
+		page.NextCursor = EncodeCursor(last, false)
+		return page
+	}
+
+	if hasMore {
+		page.NextCursor = EncodeCursor(last, false)
+	}
+	if req.Cursor != nil {
+		page.PrevCursor = EncodeCursor(first, true)
+	}
+	return page
+}
+
+// SetLinkHeader advertises the neighbouring pages as RFC 8288 links
+// relative to the request URL.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, next string, prev string) {
+	var links []string
+	if next != "" {
+		links = append(links, `<`+pageURL(r, next)+`>; rel="next"`)
+	}
+	if prev != "" {
+		links = append(links, `<`+pageURL(r, prev)+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func pageURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Set("cursor", cursor)
+	q.Del("page")
+	return r.URL.Path + "?" + q.Encode()
+}
@@ -0,0 +1,194 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := domains.Cursor{
+		CreateAt: time.Date(2024, 3, 9, 14, 7, 31, 123456789, time.FixedZone("CET", 3600)),
+		ID:       uuid.New(),
+	}
+
+	for _, backward := range []bool{false, true} {
+		got, gotBackward, err := DecodeCursor(EncodeCursor(want, backward))
+		if err != nil {
+			t.Fatalf("DecodeCursor(backward=%v): %v", backward, err)
+		}
+		if !got.CreateAt.Equal(want.CreateAt) || got.ID != want.ID {
+			t.Errorf("DecodeCursor(backward=%v) = %+v, want %+v", backward, got, want)
+		}
+		if gotBackward != backward {
+			t.Errorf("DecodeCursor(backward=%v) direction = %v", backward, gotBackward)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	id := uuid.New().String()
+	enc := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	valid := EncodeCursor(domains.Cursor{CreateAt: time.Now(), ID: uuid.New()}, false)
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", valid + "=="},
+		{"truncated", valid[:len(valid)-4]},
+		{"unknown direction", enc("x|2024-03-09T14:07:31Z|" + id)},
+		{"missing part", enc("n|2024-03-09T14:07:31Z")},
+		{"extra part", enc("n|2024-03-09T14:07:31Z|" + id + "|1")},
+		{"bad time", enc("n|yesterday|" + id)},
+		{"bad id", enc("n|2024-03-09T14:07:31Z|42")},
+		{"empty", enc("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", DefaultLimit},
+		{"limit=25", 25},
+		{"limit=0", DefaultLimit},
+		{"limit=-3", DefaultLimit},
+		{"limit=ten", DefaultLimit},
+		{"limit=1000", MaxLimit},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/posts?"+tt.query, nil)
+		if got := ParseLimit(r); got != tt.want {
+			t.Errorf("ParseLimit(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseRequestRejectsTamperedCursor(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/posts?cursor=bm90LWEtY3Vyc29y", nil)
+	if _, err := ParseRequest(r); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("ParseRequest error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestWantsCursor(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"limit=5", false},
+		{"page=2&limit=5", false},
+		{"cursor=", true},
+		{"cursor=abc&limit=5", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/posts?"+tt.query, nil)
+		if got := WantsCursor(r); got != tt.want {
+			t.Errorf("WantsCursor(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	base := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	cursors := []domains.Cursor{
+		{CreateAt: base, ID: uuid.New()},
+		{CreateAt: base.Add(-time.Minute), ID: uuid.New()},
+		{CreateAt: base.Add(-2 * time.Minute), ID: uuid.New()},
+	}
+	data := []int{0, 1, 2}
+	at := func(i int) domains.Cursor { return cursors[i] }
+	first, last := cursors[0], cursors[len(cursors)-1]
+
+	tests := []struct {
+		name     string
+		req      domains.PageRequest
+		hasMore  bool
+		wantNext *domains.Cursor
+		wantPrev *domains.Cursor
+	}{
+		{"first page", domains.PageRequest{Limit: 3}, true, &last, nil},
+		{"only page", domains.PageRequest{Limit: 3}, false, nil, nil},
+		{"middle page", domains.PageRequest{Cursor: &first, Limit: 3}, true, &last, &first},
+		{"last page", domains.PageRequest{Cursor: &first, Limit: 3}, false, nil, &first},
+		{"backward with more", domains.PageRequest{Cursor: &last, Backward: true, Limit: 3}, true, &last, &first},
+		{"backward to start", domains.PageRequest{Cursor: &last, Backward: true, Limit: 3}, false, &last, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(data, tt.req, tt.hasMore, at)
+			checkCursor(t, "next", page.NextCursor, tt.wantNext, false)
+			checkCursor(t, "prev", page.PrevCursor, tt.wantPrev, true)
+		})
+	}
+
+	empty := NewPage[int](nil, domains.PageRequest{Limit: 3}, false, at)
+	if empty.Data == nil || empty.NextCursor != "" || empty.PrevCursor != "" {
+		t.Errorf("empty page = %+v, want non-nil data and no cursors", empty)
+	}
+}
+
+func checkCursor(t *testing.T, name string, got string, want *domains.Cursor, backward bool) {
+	t.Helper()
+	if want == nil {
+		if got != "" {
+			t.Errorf("%s cursor = %q, want none", name, got)
+		}
+		return
+	}
+	if got != EncodeCursor(*want, backward) {
+		t.Errorf("%s cursor = %q, want %q", name, got, EncodeCursor(*want, backward))
+	}
+}
+
+func TestSetLinkHeader(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/posts?user_id=42&limit=5&cursor=old&page=3", nil)
+	w := httptest.NewRecorder()
+
+	SetLinkHeader(w, r, "NEXT", "PREV")
+
+	links := strings.Split(w.Header().Get("Link"), ", ")
+	if len(links) != 2 {
+		t.Fatalf("Link = %q, want two links", w.Header().Get("Link"))
+	}
+	for i, want := range []struct{ rel, cursor string }{{"next", "NEXT"}, {"prev", "PREV"}} {
+		target, rel, ok := strings.Cut(links[i], "; ")
+		if !ok || rel != `rel="`+want.rel+`"` {
+			t.Fatalf("link %d = %q, want rel=%q", i, links[i], want.rel)
+		}
+		u, err := url.Parse(strings.Trim(target, "<>"))
+		if err != nil {
+			t.Fatalf("link %d: %v", i, err)
+		}
+		q := u.Query()
+		if u.Path != "/api/v1/posts" || q.Get("cursor") != want.cursor || q.Get("user_id") != "42" || q.Get("limit") != "5" || q.Has("page") {
+			t.Errorf("link %d = %q, want the request URL with cursor=%s and no page", i, target, want.cursor)
+		}
+	}
+}
+
+func TestSetLinkHeaderWithoutNeighbours(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetLinkHeader(w, httptest.NewRequest("GET", "/api/v1/posts", nil), "", "")
+	if got := w.Header().Get("Link"); got != "" {
+		t.Errorf("Link = %q, want none", got)
+	}
+}
@@ -2,17 +2,17 @@ package post
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
 	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/post"
-	"github.com/bariscan97/clean-rest-architecture/internal/utils"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
-type authKey token.AuthKey 
-
 type Handler struct {
 	repository repo.IPostRepository
 }
@@ -35,17 +35,7 @@ func (h *Handler) GetCommentByPostID(w http.ResponseWriter, r *http.Request) {
 		parsedParentID = &uid
 	}
 
-	pageStr, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limitStr, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-
-	posts, err := h.repository.ListPosts(r.Context(), nil ,parsedParentID, pageStr, limitStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ListPostRes(posts))
+	h.listPosts(w, r, nil, parsedParentID)
 }
 
 func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
@@ -60,17 +50,83 @@ func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
 		parseduserID = &uid
 	}
 
-	pageStr, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limitStr, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	h.listPosts(w, r, parseduserID, nil)
+}
+
+// listPosts answers with the old bare array, or with a cursor page when
+// the client asks for one with ?cursor=.
+func (h *Handler) listPosts(w http.ResponseWriter, r *http.Request, userID *uuid.UUID, parentID *uuid.UUID) {
+	if !pagination.WantsCursor(r) {
+		pageStr, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		posts, err := h.repository.ListPosts(r.Context(), userID, parentID, pageStr, pagination.ParseLimit(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := h.loadViewerReactions(r.Context(), viewerID(r), posts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	posts, err := h.repository.ListPosts(r.Context(), parseduserID, nil, pageStr, limitStr)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ListPostRes(posts, viewerID(r)))
+		return
+	}
+
+	page, err := pagination.ParseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	posts, hasMore, err := h.repository.ListPostsByCursor(r.Context(), userID, parentID, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	h.writePostPage(w, r, page, posts, hasMore)
+}
+
+// writePostPage encodes posts as a cursor page with matching Link headers.
+func (h *Handler) writePostPage(w http.ResponseWriter, r *http.Request, page domains.PageRequest, posts []*domains.PostManyToMany, hasMore bool) {
+	if err := h.loadViewerReactions(r.Context(), viewerID(r), posts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := pagination.NewPage(ListPostRes(posts, viewerID(r)), page, hasMore, func(i int) domains.Cursor {
+		return cursorOf(posts[i])
+	})
+
+	pagination.SetLinkHeader(w, r, res.NextCursor, res.PrevCursor)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ListPostRes(posts))
+	json.NewEncoder(w).Encode(res)
+}
+
+func cursorOf(p *domains.PostManyToMany) domains.Cursor {
+	return domains.Cursor{CreateAt: p.CreateAt, ID: p.ID}
+}
+
+// viewerID returns the ID of the user making an optionally authenticated
+// request, or nil when it is anonymous.
+func viewerID(r *http.Request) *uuid.UUID {
+	viewer, ok := principal.PrincipalFrom(r.Context())
+	if !ok {
+		return nil
+	}
+	return &viewer.ID
+}
+
+// currentPrincipal returns the authenticated caller, answering 401 when the
+// route was mounted without authentication.
+func currentPrincipal(w http.ResponseWriter, r *http.Request) (*principal.Principal, bool) {
+	p, ok := principal.PrincipalFrom(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+	}
+	return p, ok
 }
 
 func (h *Handler) DeletePostByID(w http.ResponseWriter, r *http.Request) {
@@ -79,9 +135,17 @@ func (h *Handler) DeletePostByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return 
 	}
-	currentUserID := r.Context().Value(authKey{}).(*token.UserClaims).ID
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
 
-	if err := h.repository.DeletePostByID(r.Context(), currentUserID, postID); err != nil {
+	if domains.RolesHavePermission(claims.Roles, domains.PermissionDeleteAnyPost) {
+		err = h.repository.DeleteAnyPostByID(r.Context(), postID)
+	} else {
+		err = h.repository.DeletePostByID(r.Context(), claims.ID, postID)
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -100,11 +164,32 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error decoding request body", http.StatusBadRequest)
 		return
 	}
+
+	update := domains.PostUpdate{Title: p.Title, Content: p.Content}
+	if p.Tags != nil {
+		tags, err := normalizeTags(*p.Tags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.Tags = &tags
+	}
 	
-	currentUserID := r.Context().Value(authKey{}).(*token.UserClaims).ID
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currentUserID := caller.ID
 
-	if err := h.repository.UpdatePost(r.Context(), postID, currentUserID, utils.StructToMap(p)); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.repository.UpdatePost(r.Context(), postID, currentUserID, update); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrPostNotFound):
+			http.Error(w, "post not found", http.StatusNotFound)
+		case errors.Is(err, domains.ErrTooManyTags):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -129,10 +214,25 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
+
+	explicit, err := normalizeTags(p.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	post := CreateReqToDomain(p)
+	if post.Tags, err = domains.MergePostTags(explicit, p.Content); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	
-	currendUserID := r.Context().Value(authKey{}).(*token.UserClaims).ID
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currendUserID := caller.ID
 
-	created, err := h.repository.CreatePost(r.Context(), parsedParentID, currendUserID, CreateReqToDomain(p))
+	created, err := h.repository.CreatePost(r.Context(), parsedParentID, currendUserID, post)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -2,6 +2,7 @@ package post
 
 import (
 	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
 )
 
 func CreateReqToDomain(p CreatePostReq) *domains.Post {
@@ -12,33 +13,68 @@ func CreateReqToDomain(p CreatePostReq) *domains.Post {
 }
 
 func toCreatePostRes(p *domains.Post) CreatePostRes {
+	tags := make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		tags = append(tags, t.Name)
+	}
+
 	return CreatePostRes{
 		ID:       p.ID,
 		UserID:   p.UserID,
 		ParentID: p.ParentID,
 		Title:    p.Title,
 		Content:  p.Content,
+		Tags:     tags,
 		UpdateAt: p.UpdateAt,
 		CreateAt: p.CreateAt,
 	}
 }
 
-func ListPostRes(posts []*domains.PostManyToMany) []FetchPostRes {
+// ListPostRes maps posts for viewerID, which is nil for anonymous requests.
+func ListPostRes(posts []*domains.PostManyToMany, viewerID *uuid.UUID) []FetchPostRes {
 	var ListPosts []FetchPostRes
 
 	for _, post := range posts {
-		ListPosts = append(ListPosts, FetchPostRes{
-			ID:       post.ID,
-			UserID:   post.UserID,
-			ParentID: post.ParentID,
-			UserName: post.UserName,
-			Title:    post.Title,
-			Content:  post.Content,
-			UserImg:  post.UserImg,
-			UpdateAt: post.UpdateAt,
-			CreateAt: post.CreateAt,
-		})
+		ListPosts = append(ListPosts, toFetchPostRes(post, viewerID))
 	}
 
 	return ListPosts
 }
+
+func toFetchPostRes(post *domains.PostManyToMany, viewerID *uuid.UUID) FetchPostRes {
+	var (
+		isMine          *bool
+		viewerReactions *[]string
+	)
+	if viewerID != nil {
+		mine := post.UserID == *viewerID
+		isMine = &mine
+
+		kinds := post.ViewerReactions
+		if kinds == nil {
+			kinds = []string{}
+		}
+		viewerReactions = &kinds
+	}
+
+	reactions := post.Reactions
+	if reactions == nil {
+		reactions = map[string]int{}
+	}
+
+	return FetchPostRes{
+		ID:              post.ID,
+		UserID:          post.UserID,
+		ParentID:        post.ParentID,
+		UserName:        post.UserName,
+		Title:           post.Title,
+		Content:         post.Content,
+		UserImg:         post.UserImg,
+		Tags:            post.Tags,
+		UpdateAt:        post.UpdateAt,
+		CreateAt:        post.CreateAt,
+		Reactions:       reactions,
+		IsMine:          isMine,
+		ViewerReactions: viewerReactions,
+	}
+}
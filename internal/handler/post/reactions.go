@@ -0,0 +1,132 @@
+package post
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/post"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+var errInvalidReactionKind = errors.New("unknown reaction kind")
+
+// AddReaction reacts to a post with the kind in the URL. Repeating it is a
+// no-op.
+func (h *Handler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	postID, kind, ok := reactionTarget(w, r)
+	if !ok {
+		return
+	}
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.AddReaction(r.Context(), postID, caller.ID, kind); err != nil {
+		if errors.Is(err, repo.ErrPostNotFound) {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error adding reaction", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveReaction takes a reaction back. Removing one that is not there
+// succeeds as well.
+func (h *Handler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	postID, kind, ok := reactionTarget(w, r)
+	if !ok {
+		return
+	}
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repository.RemoveReaction(r.Context(), postID, caller.ID, kind); err != nil {
+		http.Error(w, "error removing reaction", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListReactions pages through the users who reacted to a post with a kind,
+// in the order they reacted.
+func (h *Handler) ListReactions(w http.ResponseWriter, r *http.Request) {
+	postID, kind, ok := reactionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.ParseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reactions, hasMore, err := h.repository.ListReactions(r.Context(), postID, kind, page)
+	if err != nil {
+		http.Error(w, "error listing reactions", http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]ReactionRes, 0, len(reactions))
+	for _, re := range reactions {
+		data = append(data, ReactionRes{
+			UserID:   re.UserID,
+			UserName: re.UserName,
+			UserImg:  re.UserImg,
+			Kind:     re.Kind,
+			CreateAt: re.CreateAt,
+		})
+	}
+	res := pagination.NewPage(data, page, hasMore, func(i int) domains.Cursor {
+		return domains.Cursor{CreateAt: reactions[i].CreateAt, ID: reactions[i].UserID}
+	})
+
+	pagination.SetLinkHeader(w, r, res.NextCursor, res.PrevCursor)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func reactionTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return uuid.Nil, "", false
+	}
+	kind := chi.URLParam(r, "kind")
+	if !domains.IsValidReactionKind(kind) {
+		http.Error(w, errInvalidReactionKind.Error(), http.StatusBadRequest)
+		return uuid.Nil, "", false
+	}
+	return postID, kind, true
+}
+
+// loadViewerReactions fills in which kinds viewerID reacted to posts with.
+// Anonymous requests skip the lookup.
+func (h *Handler) loadViewerReactions(ctx context.Context, viewerID *uuid.UUID, posts []*domains.PostManyToMany) error {
+	if viewerID == nil || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	reactions, err := h.repository.ViewerReactions(ctx, *viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range posts {
+		p.ViewerReactions = reactions[p.ID]
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package post
 
+// Tags are added to the #hashtags found in Content.
 type CreatePostReq struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
+// UpdatePostReq replaces the explicit tags when Tags is given; hashtags
+// always follow the content.
 type UpdatePostReq struct {
-	Title   *string `json:"title,omitempty"`
-	Content *string `json:"content,omitempty"`
+	Title   *string   `json:"title,omitempty"`
+	Content *string   `json:"content,omitempty"`
+	Tags    *[]string `json:"tags,omitempty"`
 }
\ No newline at end of file
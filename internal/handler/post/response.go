@@ -12,10 +12,13 @@ type CreatePostRes struct {
 	ParentID *uuid.UUID `json:"parent_id,omitempty"`
 	Title    string     `json:"title"`
 	Content  string     `json:"content"`
+	Tags     []string   `json:"tags"`
 	UpdateAt time.Time  `json:"update_at"`
 	CreateAt time.Time  `json:"create_at"`
 }
 
+// FetchPostRes fields below CreateAt depend on who is asking and are left
+// out for anonymous requests.
 type FetchPostRes struct {
 	ID       uuid.UUID  `json:"id"`
 	UserID   uuid.UUID  `json:"user_id"`
@@ -24,6 +27,57 @@ type FetchPostRes struct {
 	Title    string     `json:"title"`
 	Content  string     `json:"content"`
 	UserImg  *string    `json:"user_img,omitempty"`
+	Tags     []string   `json:"tags"`
 	UpdateAt time.Time  `json:"update_at"`
 	CreateAt time.Time  `json:"create_at"`
+	// Reactions counts reactions by kind; kinds nobody used are left out.
+	Reactions map[string]int `json:"reactions"`
+
+	IsMine          *bool     `json:"is_mine,omitempty"`
+	ViewerReactions *[]string `json:"viewer_reactions,omitempty"`
+}
+
+// ThreadPostRes is a post with its loaded replies. ReplyCount counts all
+// direct replies, including those not loaded.
+type ThreadPostRes struct {
+	FetchPostRes
+	ReplyCount  int              `json:"reply_count"`
+	Replies     []*ThreadPostRes `json:"replies"`
+	MoreReplies *MoreRepliesRes  `json:"more_replies,omitempty"`
+}
+
+// MoreRepliesRes points at replies that were left out. URL loads them as a
+// thread of their own; Cursor is empty when none of them were loaded yet.
+type MoreRepliesRes struct {
+	Cursor string `json:"cursor,omitempty"`
+	URL    string `json:"url"`
+}
+
+// SearchPostRes is a search hit. The highlights are HTML with matches
+// wrapped in <mark>; everything else in them is escaped.
+type SearchPostRes struct {
+	FetchPostRes
+	Rank             float64 `json:"rank"`
+	TitleHighlight   string  `json:"title_highlight"`
+	ContentHighlight string  `json:"content_highlight"`
+}
+
+// SearchPostsRes holds one page of search hits. NextOffset is set when more
+// hits follow.
+type SearchPostsRes struct {
+	Data       []SearchPostRes `json:"data"`
+	NextOffset *int            `json:"next_offset,omitempty"`
+}
+
+type TagRes struct {
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
+
+type ReactionRes struct {
+	UserID   uuid.UUID `json:"user_id"`
+	UserName string    `json:"username"`
+	UserImg  *string   `json:"user_img,omitempty"`
+	Kind     string    `json:"kind"`
+	CreateAt time.Time `json:"create_at"`
 }
@@ -0,0 +1,138 @@
+package post
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSearchQueryLength = 256
+	maxSearchOffset      = 1000
+)
+
+var errInvalidSearchTime = errors.New("must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+
+var highlightMarkup = strings.NewReplacer(
+	domains.HighlightStart, "<mark>",
+	domains.HighlightStop, "</mark>",
+)
+
+// SearchPosts runs a full-text search over post titles and content. q takes
+// websearch_to_tsquery syntax ("quoted phrases", or, -excluded). Results are
+// ranked, so they are paged with offset rather than a cursor.
+func (h *Handler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	search := domains.PostSearch{Query: strings.TrimSpace(q.Get("q"))}
+	if search.Query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(search.Query) > maxSearchQueryLength {
+		http.Error(w, "q is too long", http.StatusBadRequest)
+		return
+	}
+
+	if s := q.Get("user_id"); s != "" {
+		uid, err := uuid.Parse(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		search.UserID = &uid
+	}
+
+	var err error
+	if search.From, err = parseSearchTime(q.Get("from"), false); err != nil {
+		http.Error(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.To, err = parseSearchTime(q.Get("to"), true); err != nil {
+		http.Error(w, "to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.From != nil && search.To != nil && !search.From.Before(*search.To) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := boundedInt(q.Get("limit"), pagination.DefaultLimit, 1, pagination.MaxLimit)
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.Offset, err = boundedInt(q.Get("offset"), 0, 0, maxSearchOffset); err != nil {
+		http.Error(w, "offset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// One extra row tells whether another page follows.
+	search.Limit = limit + 1
+
+	results, err := h.repository.SearchPosts(r.Context(), search)
+	if err != nil {
+		http.Error(w, "error searching posts", http.StatusInternalServerError)
+		return
+	}
+
+	res := SearchPostsRes{Data: make([]SearchPostRes, 0, len(results))}
+	if len(results) > limit {
+		results = results[:limit]
+		next := search.Offset + limit
+		res.NextOffset = &next
+	}
+
+	viewer := viewerID(r)
+	posts := make([]*domains.PostManyToMany, len(results))
+	for i, p := range results {
+		posts[i] = &p.PostManyToMany
+	}
+	if err := h.loadViewerReactions(r.Context(), viewer, posts); err != nil {
+		http.Error(w, "error searching posts", http.StatusInternalServerError)
+		return
+	}
+	for _, p := range results {
+		res.Data = append(res.Data, SearchPostRes{
+			FetchPostRes:     toFetchPostRes(&p.PostManyToMany, viewer),
+			Rank:             p.Rank,
+			TitleHighlight:   highlight(p.TitleHighlight),
+			ContentHighlight: highlight(p.ContentHighlight),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// parseSearchTime accepts RFC 3339 timestamps or plain dates. A plain date
+// used as the upper bound includes that whole day.
+func parseSearchTime(s string, upper bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, errInvalidSearchTime
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// highlight escapes a headline and turns its match delimiters into <mark>
+// elements, so clients can render it as HTML.
+func highlight(s string) string {
+	return highlightMarkup.Replace(html.EscapeString(s))
+}
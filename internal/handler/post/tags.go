@@ -0,0 +1,102 @@
+package post
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+// ListTagPosts pages through the posts carrying a tag, newest first.
+func (h *Handler) ListTagPosts(w http.ResponseWriter, r *http.Request) {
+	tag, err := domains.NormalizeTag(chi.URLParam(r, "tag"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	posts, hasMore, err := h.repository.ListPostsByTag(r.Context(), tag, page)
+	if err != nil {
+		http.Error(w, "error listing posts", http.StatusInternalServerError)
+		return
+	}
+
+	h.writePostPage(w, r, page, posts, hasMore)
+}
+
+// SearchTags suggests tags starting with ?prefix=, most used first. Without
+// a prefix it lists the most used tags.
+func (h *Handler) SearchTags(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	prefix := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q.Get("prefix")), "#"))
+	if !isTagPrefix(prefix) {
+		http.Error(w, domains.ErrInvalidTag.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := boundedInt(q.Get("limit"), defaultTagSuggestions, 1, maxTagSuggestions)
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.repository.SearchTags(r.Context(), prefix, limit)
+	if err != nil {
+		http.Error(w, "error searching tags", http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]TagRes, 0, len(tags))
+	for _, t := range tags {
+		res = append(res, TagRes{Name: t.Name, PostCount: t.PostCount})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// normalizeTags normalises the tags a client sent explicitly.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > domains.MaxPostTags {
+		return nil, domains.ErrTooManyTags
+	}
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		name, err := domains.NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// isTagPrefix reports whether s could start a tag. Unlike a whole tag it
+// does not need a letter yet.
+func isTagPrefix(s string) bool {
+	if utf8.RuneCountInString(s) > domains.MaxTagLength {
+		return false
+	}
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package post
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/post"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth     = 5
+	maxThreadDepth         = 10
+	defaultRepliesPerLevel = 10
+	maxRepliesPerLevel     = 50
+)
+
+// GetThread returns a post with its replies nested below it. Replies that
+// did not fit, either past the per-level limit or below max_depth, are
+// announced through more_replies, whose url continues the thread there.
+func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
+	rootID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	maxDepth, err := boundedInt(q.Get("max_depth"), defaultThreadDepth, 0, maxThreadDepth)
+	if err != nil {
+		http.Error(w, "max_depth: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := boundedInt(q.Get("limit"), defaultRepliesPerLevel, 1, maxRepliesPerLevel)
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var after *domains.Cursor
+	if s := q.Get("after"); s != "" {
+		var backward bool
+		after, backward, err = pagination.DecodeCursor(s)
+		if err != nil || backward {
+			http.Error(w, pagination.ErrInvalidCursor.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	posts, err := h.repository.GetThread(r.Context(), rootID, maxDepth, limit, after)
+	if err != nil {
+		if errors.Is(err, repo.ErrPostNotFound) {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error loading thread", http.StatusInternalServerError)
+		return
+	}
+
+	viewer := viewerID(r)
+	loaded := make([]*domains.PostManyToMany, len(posts))
+	for i, p := range posts {
+		loaded[i] = &p.PostManyToMany
+	}
+	if err := h.loadViewerReactions(r.Context(), viewer, loaded); err != nil {
+		http.Error(w, "error loading thread", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildThread(posts, limit, maxDepth, viewer))
+}
+
+// buildThread nests the flat rows from GetThread, which come ordered by
+// depth so every parent is seen before its replies.
+func buildThread(posts []*domains.ThreadPost, limit int, maxDepth int, viewerID *uuid.UUID) *ThreadPostRes {
+	nodes := make(map[uuid.UUID]*ThreadPostRes, len(posts))
+	last := make(map[uuid.UUID]*domains.ThreadPost)
+	truncated := make(map[uuid.UUID]bool)
+	var root *ThreadPostRes
+
+	for _, p := range posts {
+		if p.Depth == 0 {
+			root = newThreadPostRes(p, viewerID)
+			nodes[p.ID] = root
+			continue
+		}
+		parent, ok := nodes[*p.ParentID]
+		if !ok {
+			continue
+		}
+		if p.Position > limit {
+			truncated[*p.ParentID] = true
+			continue
+		}
+		node := newThreadPostRes(p, viewerID)
+		nodes[p.ID] = node
+		parent.Replies = append(parent.Replies, node)
+		last[*p.ParentID] = p
+	}
+
+	for _, p := range posts {
+		node, ok := nodes[p.ID]
+		if !ok {
+			continue
+		}
+		switch {
+		case truncated[p.ID]:
+			node.MoreReplies = moreReplies(p.ID, cursorOf(&last[p.ID].PostManyToMany))
+		case p.Depth == maxDepth && p.ReplyCount > 0:
+			node.MoreReplies = &MoreRepliesRes{URL: threadURL(p.ID, "")}
+		}
+	}
+
+	return root
+}
+
+func newThreadPostRes(p *domains.ThreadPost, viewerID *uuid.UUID) *ThreadPostRes {
+	return &ThreadPostRes{
+		FetchPostRes: toFetchPostRes(&p.PostManyToMany, viewerID),
+		ReplyCount:   p.ReplyCount,
+		Replies:      []*ThreadPostRes{},
+	}
+}
+
+func moreReplies(parentID uuid.UUID, after domains.Cursor) *MoreRepliesRes {
+	cursor := pagination.EncodeCursor(after, false)
+	return &MoreRepliesRes{Cursor: cursor, URL: threadURL(parentID, cursor)}
+}
+
+func threadURL(postID uuid.UUID, cursor string) string {
+	u := fmt.Sprintf("/api/v1/posts/%s/thread", postID)
+	if cursor != "" {
+		u += "?after=" + url.QueryEscape(cursor)
+	}
+	return u
+}
+
+// boundedInt parses an optional query parameter, falling back to def and
+// rejecting values outside [min, max].
+func boundedInt(s string, def int, min int, max int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("must be a number")
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("must be between %d and %d", min, max)
+	}
+	return n, nil
+}
@@ -0,0 +1,159 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DeleteUser deactivates the caller's account. Its content disappears at
+// once, but nothing is removed until the grace period ends; logging in
+// before then restores the account.
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currentUserID := claims.ID
+
+	purgeAfter, err := h.repository.DeactivateUser(r.Context(), currentUserID, h.cfg.Account.DeletionGracePeriod)
+	if err != nil {
+		http.Error(w, "error deleting user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.revokeAllSessions(r.Context(), currentUserID); err != nil {
+		http.Error(w, "error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+	if h.Cookies.Enabled() {
+		h.Cookies.Clear(w)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(DeactivationRes{
+		Status:     domains.UserStatusPendingDeletion,
+		PurgeAfter: purgeAfter,
+	})
+}
+
+// SuspendUser blocks an account until an administrator lifts the
+// suspension. The user is logged out everywhere and their content hidden.
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req SuspendUserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "a reason is required", http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if admin.ID == id {
+		http.Error(w, "cannot suspend yourself", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repository.SuspendUser(r.Context(), id, strings.TrimSpace(req.Reason)); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error suspending user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.revokeAllSessions(r.Context(), id); err != nil {
+		http.Error(w, "error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	zap.L().Info("user suspended",
+		zap.String("userID", id.String()),
+		zap.String("adminID", admin.ID.String()),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repository.UnsuspendUser(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error unsuspending user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// admitLogin is checked before any login is completed. Suspended accounts
+// are turned away, and logging in is how a user cancels a pending deletion.
+func (h *Handler) admitLogin(w http.ResponseWriter, r *http.Request, user *domains.User) bool {
+	switch user.Status() {
+	case domains.UserStatusSuspended:
+		http.Error(w, "account suspended", http.StatusForbidden)
+		return false
+	case domains.UserStatusPendingDeletion:
+		restored, err := h.repository.ReactivateUser(r.Context(), user.ID)
+		if err != nil {
+			http.Error(w, "error restoring account", http.StatusInternalServerError)
+			return false
+		}
+		if !restored {
+			http.Error(w, "account has been deleted", http.StatusGone)
+			return false
+		}
+		user.DeactivatedAt, user.PurgeAfter = nil, nil
+		zap.L().Info("pending account deletion cancelled by login", zap.String("userID", user.ID.String()))
+	}
+	return true
+}
+
+// StartAccountPurger deletes accounts whose grace period has ended every
+// interval until ctx is cancelled.
+func (h *Handler) StartAccountPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := h.repository.PurgeDeactivatedUsers(ctx)
+			if err != nil {
+				zap.L().Error("error purging deactivated accounts", zap.Error(err))
+				continue
+			}
+			if purged > 0 {
+				zap.L().Info("purged deactivated accounts", zap.Int64("count", purged))
+			}
+		}
+	}
+}
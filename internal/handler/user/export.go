@@ -0,0 +1,135 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/dataexport"
+	"github.com/bariscan97/clean-rest-architecture/pkg/blobstore"
+	"github.com/bariscan97/clean-rest-architecture/pkg/signedurl"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RequestDataExport queues an archive of everything stored about the
+// caller. It is built in the background; poll GetDataExport for the link.
+func (h *Handler) RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.exports.CreateExport(r.Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, dataexport.ErrExportInProgress) {
+			http.Error(w, "a data export is already in progress", http.StatusConflict)
+			return
+		}
+		http.Error(w, "error requesting data export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(h.toDataExportRes(export))
+}
+
+func (h *Handler) GetDataExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.exports.GetLatestExport(r.Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, dataexport.ErrExportNotFound) {
+			http.Error(w, "no data export requested", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error getting data export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.toDataExportRes(export))
+}
+
+// DownloadDataExport serves the archive to whoever holds a valid signed
+// link; the signature stands in for authentication.
+func (h *Handler) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	if err := h.exportLinks.Verify(r, time.Now()); err != nil {
+		if errors.Is(err, signedurl.ErrExpired) {
+			http.Error(w, "download link has expired", http.StatusGone)
+			return
+		}
+		http.Error(w, "invalid download link", http.StatusForbidden)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.exports.GetExport(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, dataexport.ErrExportNotFound) {
+			http.Error(w, "data export not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error getting data export", http.StatusInternalServerError)
+		return
+	}
+	if !export.IsDownloadable(time.Now()) {
+		http.Error(w, "data export is not available", http.StatusGone)
+		return
+	}
+
+	archive, err := h.blobs.Open(r.Context(), *export.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			http.Error(w, "data export is not available", http.StatusGone)
+			return
+		}
+		http.Error(w, "error opening data export", http.StatusInternalServerError)
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="data-export-%s.zip"`, export.CreateAt.UTC().Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, archive); err != nil {
+		zap.L().Error("error sending data export", zap.String("exportID", export.ID.String()), zap.Error(err))
+	}
+}
+
+func (h *Handler) toDataExportRes(export *domains.DataExport) DataExportRes {
+	res := DataExportRes{
+		ID:          export.ID,
+		Status:      export.Status,
+		CreateAt:    export.CreateAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+
+	now := time.Now()
+	if export.IsDownloadable(now) {
+		linkExpires := now.Add(h.cfg.Export.LinkTTL)
+		if export.ExpiresAt.Before(linkExpires) {
+			linkExpires = *export.ExpiresAt
+		}
+		path := fmt.Sprintf("/api/v1/user/export/%s/download", export.ID)
+		res.DownloadURL = strings.TrimRight(h.cfg.App.PublicURL, "/") + h.exportLinks.Sign(path, linkExpires)
+		res.DownloadURLExpiresAt = &linkExpires
+	}
+	return res
+}
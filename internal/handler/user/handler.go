@@ -1,28 +1,115 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/handler/pagination"
+	"github.com/bariscan97/clean-rest-architecture/pkg/authcookie"
+	"github.com/bariscan97/clean-rest-architecture/pkg/blobstore"
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/bariscan97/clean-rest-architecture/pkg/mailer"
+	"github.com/bariscan97/clean-rest-architecture/pkg/oidc"
+	"github.com/bariscan97/clean-rest-architecture/pkg/password"
+	"github.com/bariscan97/clean-rest-architecture/pkg/principal"
+	"github.com/bariscan97/clean-rest-architecture/pkg/signedurl"
 	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/dataexport"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/identity"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/impersonation"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/loginattempt"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/mfa"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/passwordreset"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/pat"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/refresh"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/session"
 	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
 	"github.com/bariscan97/clean-rest-architecture/internal/utils"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
-type authKey token.AuthKey 
+const (
+	accessTokenDuration  = 15 * time.Minute
+	refreshTokenDuration = 30 * 24 * time.Hour
+)
 
 type Handler struct {
-	repository repo.IUserRepository
-	TokenMaker *token.JWTMaker
+	cfg            *config.Config
+	repository     repo.IUserRepository
+	refreshTokens  refresh.IRefreshTokenRepository
+	passwordResets passwordreset.IPasswordResetRepository
+	mfa            mfa.IMFARepository
+	loginAttempts  loginattempt.ILoginAttemptRepository
+	passwords      *password.Hasher
+	passwordPolicy *password.Policy
+	identities     identity.IIdentityRepository
+	oidcProviders  *oidc.Registry
+	exports        dataexport.IDataExportRepository
+	blobs          blobstore.Store
+	exportLinks    *signedurl.Signer
+	mailer         mailer.Mailer
+	TokenMaker     *token.JWTMaker
+	Revocations    *token.RevocationList
+
+	PersonalAccessTokens pat.IPersonalAccessTokenRepository
+	Sessions             session.ISessionRepository
+	Cookies              *authcookie.Manager
+	Impersonations       impersonation.IImpersonationRepository
+}
+
+// Deps groups everything NewUserHandler needs.
+type Deps struct {
+	Config               *config.Config
+	Users                repo.IUserRepository
+	RefreshTokens        refresh.IRefreshTokenRepository
+	PersonalAccessTokens pat.IPersonalAccessTokenRepository
+	Sessions             session.ISessionRepository
+	PasswordResets       passwordreset.IPasswordResetRepository
+	MFA                  mfa.IMFARepository
+	LoginAttempts        loginattempt.ILoginAttemptRepository
+	Passwords            *password.Hasher
+	PasswordPolicy       *password.Policy
+	Identities           identity.IIdentityRepository
+	OIDCProviders        *oidc.Registry
+	Exports              dataexport.IDataExportRepository
+	Blobs                blobstore.Store
+	ExportLinks          *signedurl.Signer
+	Revocations          *token.RevocationList
+	TokenMaker           *token.JWTMaker
+	Mailer               mailer.Mailer
+	Cookies              *authcookie.Manager
+	Impersonations       impersonation.IImpersonationRepository
 }
 
-func NewUserHandler(repository repo.IUserRepository, secretKey string) *Handler {
+func NewUserHandler(deps Deps) *Handler {
 	return &Handler{
-		repository: repository,
-		TokenMaker: token.NewJWTMaker(secretKey),
+		cfg:                  deps.Config,
+		repository:           deps.Users,
+		refreshTokens:        deps.RefreshTokens,
+		passwordResets:       deps.PasswordResets,
+		mfa:                  deps.MFA,
+		loginAttempts:        deps.LoginAttempts,
+		passwords:            deps.Passwords,
+		passwordPolicy:       deps.PasswordPolicy,
+		identities:           deps.Identities,
+		oidcProviders:        deps.OIDCProviders,
+		exports:              deps.Exports,
+		blobs:                deps.Blobs,
+		exportLinks:          deps.ExportLinks,
+		mailer:               deps.Mailer,
+		TokenMaker:           deps.TokenMaker,
+		Revocations:          deps.Revocations,
+		PersonalAccessTokens: deps.PersonalAccessTokens,
+		Sessions:             deps.Sessions,
+		Cookies:              deps.Cookies,
+		Impersonations:       deps.Impersonations,
 	}
 }
 
@@ -38,6 +125,10 @@ func (h *Handler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !user.IsActive() {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(toUserRes(user))
@@ -51,7 +142,12 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashed, err := utils.HashPassword(u.Password)
+	if errs := h.validateRegister(u); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	hashed, err := h.passwords.Hash(u.Password)
 	if err != nil {
 		http.Error(w, "error hashing password", http.StatusInternalServerError)
 		return
@@ -64,6 +160,10 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.sendVerificationEmail(r.Context(), created); err != nil {
+		zap.L().Error("error sending verification email", zap.String("userID", created.ID.String()), zap.Error(err))
+	}
+
 	res := toUserRes(created)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -71,10 +171,37 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if !pagination.WantsCursor(r) {
+		h.listUsersByPage(w, r)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, hasMore, err := h.repository.ListUsersByCursor(r.Context(), page)
+	if err != nil {
+		http.Error(w, "error listing users", http.StatusInternalServerError)
+		return
+	}
+
+	res := pagination.NewPage(ListUserRes(users), page, hasMore, func(i int) domains.Cursor {
+		return domains.Cursor{CreateAt: users[i].CreateAt, ID: users[i].ID}
+	})
+
+	pagination.SetLinkHeader(w, r, res.NextCursor, res.PrevCursor)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// listUsersByPage serves the old offset listing as a bare array.
+func (h *Handler) listUsersByPage(w http.ResponseWriter, r *http.Request) {
 	pageStr, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limitStr, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 
-	users, err := h.repository.ListUsers(r.Context(), pageStr, limitStr)
+	users, err := h.repository.ListUsers(r.Context(), pageStr, pagination.ParseLimit(r))
 
 	if err != nil {
 		http.Error(w, "error listing users", http.StatusInternalServerError)
@@ -92,10 +219,18 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
 
 	if u.Password != "" {
-		hashed, err := utils.HashPassword(u.Password)
+		if errs := h.validatePassword("password", u.Password); len(errs) > 0 {
+			writeValidationErrors(w, errs)
+			return
+		}
+
+		hashed, err := h.passwords.Hash(u.Password)
 		if err != nil {
 			http.Error(w, "error hashing password", http.StatusInternalServerError)
 			return
@@ -104,21 +239,52 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	currentUserID := claims.ID
-	
+	fields := utils.StructToMap(u)
+
+	// A new address has to be confirmed again before it counts as verified.
+	var changedEmail *domains.User
+	if u.Email != "" {
+		user, err := h.repository.GetUserByIdentifier(r.Context(), currentUserID.String())
+		if err != nil {
+			http.Error(w, "error updating user", http.StatusInternalServerError)
+			return
+		}
+		if u.Email != user.Email {
+			fields["email_verified_at"] = nil
+			fields["email_verification_sent_at"] = nil
+			user.Email = u.Email
+			changedEmail = user
+		}
+	}
 
-	if err := h.repository.UpdateUserByID(r.Context(), currentUserID, utils.StructToMap(u)); err != nil {
+	if err := h.repository.UpdateUserByID(r.Context(), currentUserID, fields); err != nil {
 		http.Error(w, "error updating user", http.StatusInternalServerError)
 		return
 	}
 
+	if changedEmail != nil {
+		// Access tokens carry the verified flag; cut them off so the next
+		// refresh picks up the unverified address.
+		if err := h.Revocations.RevokeUser(r.Context(), currentUserID, accessTokenDuration); err != nil {
+			zap.L().Error("error revoking access tokens after email change", zap.String("userID", currentUserID.String()), zap.Error(err))
+		}
+		if err := h.sendVerificationEmail(r.Context(), changedEmail); err != nil {
+			zap.L().Error("error sending verification email", zap.String("userID", currentUserID.String()), zap.Error(err))
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	currentUserID := r.Context().Value(authKey{}).(*token.UserClaims).ID
+func (h *Handler) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.repository.DeleteUserByID(r.Context(), currentUserID); err != nil {
+	if err := h.repository.DeleteUserByID(r.Context(), id); err != nil {
 		http.Error(w, "error deleting user", http.StatusInternalServerError)
 		return
 	}
@@ -126,37 +292,319 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) UpdateUserRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateUserRolesReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	for _, role := range req.Roles {
+		if !domains.IsValidRole(role) {
+			http.Error(w, "unknown role: "+role, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.repository.UpdateUserRoles(r.Context(), id, req.Roles); err != nil {
+		http.Error(w, "error updating user roles", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var u LoginUserReq
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
 		http.Error(w, "error decoding request body", http.StatusBadRequest)
 		return
 	}
+	ip := utils.ClientIP(r)
+
 	user, err := h.repository.GetUserByIdentifier(r.Context(), u.Identifier)
 
 	if err != nil {
-		http.Error(w, "error getting user", http.StatusInternalServerError)
+		if !errors.Is(err, repo.ErrUserNotFound) {
+			http.Error(w, "error getting user", http.StatusInternalServerError)
+			return
+		}
+
+		key := identifierKey(u.Identifier)
+		if h.loginBlocked(w, r, ipKey(ip), key) {
+			return
+		}
+		h.passwords.VerifyDummy(u.Password)
+		h.recordLoginFailure(r.Context(), ip, nil, key)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	key := accountKey(user.ID)
+	if h.loginBlocked(w, r, ipKey(ip), key) {
+		return
+	}
+
+	ok, needsRehash, err := h.passwords.Verify(u.Password, user.Password)
+	if err != nil {
+		http.Error(w, "error checking password", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.recordLoginFailure(r.Context(), ip, &user.ID, key)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	if needsRehash {
+		h.rehashPassword(r.Context(), user.ID, u.Password)
+	}
+
+	if err := h.loginAttempts.Reset(r.Context(), key); err != nil {
+		zap.L().Error("error resetting login failures", zap.Error(err))
+	}
+
+	mfaRequired, err := h.mfaRequired(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "error checking mfa", http.StatusInternalServerError)
+		return
+	}
+	if mfaRequired {
+		h.writeMFAChallenge(w, user)
 		return
 	}
 
-	if err = utils.CheckPassword(u.Password, user.Password); err != nil {
-		http.Error(w, "wrong password", http.StatusUnauthorized)
+	h.writeLogin(w, r, user)
+}
+
+// writeLogin starts a new session for user on the requesting device and
+// issues a fresh access and refresh token pair bound to it.
+func (h *Handler) writeLogin(w http.ResponseWriter, r *http.Request, user *domains.User) {
+	if !h.admitLogin(w, r, user) {
 		return
 	}
 
-	accessToken, accessClaims, err := h.TokenMaker.CreateToken(user.ID, user.UserName, user.Email, 15*time.Minute)
+	sess, err := h.Sessions.CreateSession(r.Context(), &domains.Session{
+		UserID:    user.ID,
+		UserAgent: truncate(r.UserAgent(), maxUserAgentLength),
+		IP:        utils.ClientIP(r),
+	})
+	if err != nil {
+		http.Error(w, "error creating session", http.StatusInternalServerError)
+		return
+	}
+
+	opts := append(accessClaimOptions(user), token.WithSessionID(sess.ID))
+	accessToken, accessClaims, err := h.TokenMaker.CreateToken(user.ID, user.UserName, user.Email, accessTokenDuration, opts...)
 	if err != nil {
 		http.Error(w, "error creating token", http.StatusInternalServerError)
 		return
 	}
 
+	refreshToken, refreshClaims, err := h.createRefreshToken(r.Context(), user.ID, sess.ID)
+	if err != nil {
+		http.Error(w, "error creating refresh token", http.StatusInternalServerError)
+		return
+	}
+
 	res := LoginUserRes{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessClaims.RegisteredClaims.ExpiresAt.Time,
-		User:                 toUserRes(user),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessClaims.RegisteredClaims.ExpiresAt.Time,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshClaims.ExpiresAt,
+		User:                  toUserRes(user),
+	}
+
+	if h.Cookies.Enabled() {
+		csrf, err := h.Cookies.SetTokens(w, res.AccessToken, res.AccessTokenExpiresAt, res.RefreshToken, res.RefreshTokenExpiresAt)
+		if err != nil {
+			http.Error(w, "error setting cookies", http.StatusInternalServerError)
+			return
+		}
+		res.AccessToken, res.RefreshToken, res.CSRFToken = "", "", csrf
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
 }
+
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		fromCookie, ok := h.Cookies.RefreshToken(r)
+		if !ok {
+			http.Error(w, "refresh token is required", http.StatusBadRequest)
+			return
+		}
+		if !h.Cookies.ValidCSRF(r) {
+			http.Error(w, "invalid csrf token", http.StatusForbidden)
+			return
+		}
+		req.RefreshToken = fromCookie
+	}
+
+	plain, err := token.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "error creating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	rotated, err := h.refreshTokens.RotateRefreshToken(r.Context(), token.HashOpaqueToken(req.RefreshToken), &domains.RefreshToken{
+		TokenHash: token.HashOpaqueToken(plain),
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, refresh.ErrTokenReused):
+			zap.L().Warn("refresh token reuse detected, token family revoked")
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+		case errors.Is(err, refresh.ErrTokenNotFound),
+			errors.Is(err, refresh.ErrTokenExpired),
+			errors.Is(err, refresh.ErrTokenRevoked):
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+		default:
+			http.Error(w, "error refreshing token", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	user, err := h.repository.GetUserByIdentifier(r.Context(), rotated.UserID.String())
+	if err != nil {
+		http.Error(w, "error getting user", http.StatusInternalServerError)
+		return
+	}
+	if !user.IsActive() {
+		http.Error(w, "account is not active", http.StatusUnauthorized)
+		return
+	}
+
+	// Every family belongs to exactly one session and shares its ID.
+	opts := append(accessClaimOptions(user), token.WithSessionID(rotated.FamilyID))
+	accessToken, accessClaims, err := h.TokenMaker.CreateToken(user.ID, user.UserName, user.Email, accessTokenDuration, opts...)
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+
+	res := RefreshTokenRes{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessClaims.RegisteredClaims.ExpiresAt.Time,
+		RefreshToken:          plain,
+		RefreshTokenExpiresAt: rotated.ExpiresAt,
+	}
+
+	if h.Cookies.Enabled() {
+		csrf, err := h.Cookies.SetTokens(w, res.AccessToken, res.AccessTokenExpiresAt, res.RefreshToken, res.RefreshTokenExpiresAt)
+		if err != nil {
+			http.Error(w, "error setting cookies", http.StatusInternalServerError)
+			return
+		}
+		res.AccessToken, res.RefreshToken, res.CSRFToken = "", "", csrf
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req LogoutReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	switch claims.Kind {
+	case principal.KindAPIToken:
+		http.Error(w, "personal access tokens are revoked via /api/v1/user/tokens", http.StatusBadRequest)
+		return
+	case principal.KindService:
+		http.Error(w, "signed service requests have no session to log out of", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Revocations.Revoke(r.Context(), claims.UserClaims); err != nil {
+		http.Error(w, "error revoking token", http.StatusInternalServerError)
+		return
+	}
+
+	if claims.SessionID != nil {
+		if err := h.Sessions.RevokeSession(r.Context(), claims.ID, *claims.SessionID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			http.Error(w, "error revoking session", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = h.Cookies.RefreshToken(r)
+	}
+	if req.RefreshToken != "" {
+		if err := h.refreshTokens.RevokeFamilyByTokenHash(r.Context(), claims.ID, token.HashOpaqueToken(req.RefreshToken)); err != nil {
+			http.Error(w, "error revoking refresh token", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if h.Cookies.Enabled() {
+		h.Cookies.Clear(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.TokenMaker.JWKS())
+}
+
+// createRefreshToken starts the rotation family for sessionID and returns the
+// plain token alongside its stored record.
+func (h *Handler) createRefreshToken(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) (string, *domains.RefreshToken, error) {
+	plain, err := token.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	created, err := h.refreshTokens.CreateRefreshToken(ctx, &domains.RefreshToken{
+		UserID:    userID,
+		FamilyID:  sessionID,
+		TokenHash: token.HashOpaqueToken(plain),
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return plain, created, nil
+}
+
+func accessClaimOptions(user *domains.User) []token.ClaimsOption {
+	return []token.ClaimsOption{
+		token.WithRoles(user.Roles),
+		token.WithEmailVerified(user.EmailVerifiedAt != nil),
+	}
+}
+
+// currentPrincipal returns the authenticated caller, answering 401 when the
+// route was mounted without authentication.
+func currentPrincipal(w http.ResponseWriter, r *http.Request) (*principal.Principal, bool) {
+	p, ok := principal.PrincipalFrom(r.Context())
+	if !ok {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+	}
+	return p, ok
+}
@@ -0,0 +1,106 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
+	"github.com/bariscan97/clean-rest-architecture/internal/utils"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const impersonationTokenDuration = 15 * time.Minute
+
+// ImpersonateUser issues a short-lived access token for the target user
+// that names the calling admin in its act claim. No refresh token or
+// session is created, so it cannot be extended.
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	targetID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ImpersonateUserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "a reason is required", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if actor.ID == targetID {
+		http.Error(w, "cannot impersonate yourself", http.StatusBadRequest)
+		return
+	}
+
+	target, err := h.repository.GetUserByIdentifier(r.Context(), targetID.String())
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error getting user", http.StatusInternalServerError)
+		return
+	}
+
+	if domains.RolesHavePermission(target.Roles, domains.PermissionManageUsers) ||
+		domains.RolesHavePermission(target.Roles, domains.PermissionImpersonate) {
+		http.Error(w, "administrators cannot be impersonated", http.StatusForbidden)
+		return
+	}
+	if !target.IsActive() {
+		http.Error(w, "account is not active", http.StatusConflict)
+		return
+	}
+
+	opts := append(accessClaimOptions(target), token.WithActor(token.Actor{
+		Subject:  actor.RegisteredClaims.Subject,
+		ID:       actor.ID,
+		UserName: actor.UserName,
+	}))
+	accessToken, claims, err := h.TokenMaker.CreateToken(target.ID, target.UserName, target.Email, impersonationTokenDuration, opts...)
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Impersonations.RecordEvent(r.Context(), &domains.ImpersonationAuditEntry{
+		TokenID: claims.RegisteredClaims.ID,
+		ActorID: actor.ID,
+		UserID:  target.ID,
+		Event:   domains.ImpersonationEventStart,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		IP:      utils.ClientIP(r),
+		Reason:  req.Reason,
+	}); err != nil {
+		http.Error(w, "error recording audit event", http.StatusInternalServerError)
+		return
+	}
+
+	zap.L().Warn("impersonation started",
+		zap.String("actorID", actor.ID.String()),
+		zap.String("userID", target.ID.String()),
+		zap.String("tokenID", claims.RegisteredClaims.ID),
+		zap.String("reason", req.Reason),
+	)
+
+	res := ImpersonationRes{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
+		User:                 toUserRes(target),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,130 @@
+package user
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func accountKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// identifierKey is used for identifiers that match no account, so unknown
+// usernames lock out exactly like real ones.
+func identifierKey(identifier string) string {
+	return "identifier:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.loginAttempts.Reset(r.Context(), accountKey(id)); err != nil {
+		http.Error(w, "error unlocking user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loginBlocked answers 429 with Retry-After and returns true when any of
+// keys is still blocked.
+func (h *Handler) loginBlocked(w http.ResponseWriter, r *http.Request, keys ...string) bool {
+	until, err := h.loginAttempts.BlockedUntil(r.Context(), keys...)
+	if err != nil {
+		http.Error(w, "error checking login attempts", http.StatusInternalServerError)
+		return true
+	}
+	if until == nil {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(*until).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	http.Error(w, "too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return true
+}
+
+// recordLoginFailure counts a failed attempt against both the account key
+// and the client IP and blocks them according to the lockout policy.
+func (h *Handler) recordLoginFailure(ctx context.Context, ip string, userID *uuid.UUID, key string) {
+	policy := h.cfg.Auth.Lockout
+
+	h.recordFailure(ctx, ip, userID, key, policy.AccountFreeAttempts, policy.AccountThreshold)
+	h.recordFailure(ctx, ip, nil, ipKey(ip), policy.IPFreeAttempts, policy.IPThreshold)
+}
+
+func (h *Handler) recordFailure(ctx context.Context, ip string, userID *uuid.UUID, key string, free int, threshold int) {
+	policy := h.cfg.Auth.Lockout
+
+	failures, err := h.loginAttempts.RecordFailure(ctx, key, policy.Window)
+	if err != nil {
+		zap.L().Error("error recording login failure", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	delay, locked := loginBackoff(failures, free, threshold, policy.BaseDelay, policy.MaxDelay, policy.LockoutDuration)
+	if delay == 0 {
+		return
+	}
+
+	until := time.Now().Add(delay)
+	if err := h.loginAttempts.Block(ctx, key, until); err != nil {
+		zap.L().Error("error blocking login", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	if locked {
+		zap.L().Warn("login locked out", zap.String("key", key), zap.String("ip", ip), zap.Int("failures", failures))
+		if err := h.loginAttempts.RecordLockout(ctx, &domains.LockoutEvent{
+			Key:         key,
+			UserID:      userID,
+			IP:          ip,
+			Failures:    failures,
+			LockedUntil: until,
+		}); err != nil {
+			zap.L().Error("error recording lockout event", zap.Error(err))
+		}
+	}
+}
+
+// loginBackoff returns how long to refuse further attempts after failures
+// consecutive failures. The first free failures cost nothing, then the delay
+// doubles from base up to max, and from threshold on the subject is locked
+// out for lockout.
+func loginBackoff(failures int, free int, threshold int, base time.Duration, max time.Duration, lockout time.Duration) (time.Duration, bool) {
+	if failures >= threshold {
+		return lockout, true
+	}
+	if failures <= free {
+		return 0, false
+	}
+
+	delay := base
+	for i := free + 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay, false
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
 )
 
 func CreateReqToDomain(u RegisterUserReq) *domains.User {
@@ -18,6 +19,8 @@ func toUserRes(u *domains.User) UserRes {
 		UserName: u.UserName,
 		ImgUrl: u.ImgUrl,
 		Email: u.Email,
+		Roles: u.Roles,
+		EmailVerified: u.EmailVerifiedAt != nil,
 	}
 }
 
@@ -25,9 +28,44 @@ func ListUserRes(users []*domains.User) []UserRes {
 	var ListUsers []UserRes
 	
 	for _, user := range users {
-		ListUsers = append(ListUsers, toUserRes(user))
-			
+		res := toUserRes(user)
+		res.Status = user.Status()
+		ListUsers = append(ListUsers, res)
 	}
 	
 	return ListUsers
 }
+
+func toPersonalAccessTokenRes(t *domains.PersonalAccessToken) PersonalAccessTokenRes {
+	return PersonalAccessTokenRes{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreateAt:   t.CreateAt,
+	}
+}
+
+func ListPersonalAccessTokenRes(tokens []*domains.PersonalAccessToken) []PersonalAccessTokenRes {
+	res := make([]PersonalAccessTokenRes, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, toPersonalAccessTokenRes(t))
+	}
+	return res
+}
+
+func ListSessionRes(sessions []*domains.Session, current *uuid.UUID) []SessionRes {
+	res := make([]SessionRes, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, SessionRes{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreateAt:   s.CreateAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    current != nil && *current == s.ID,
+		})
+	}
+	return res
+}
@@ -0,0 +1,233 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/mfa"
+	"github.com/bariscan97/clean-rest-architecture/internal/utils"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/bariscan97/clean-rest-architecture/pkg/totp"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeDuration = 5 * time.Minute
+	recoveryCodeCount    = 10
+)
+
+func (h *Handler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		http.Error(w, "error generating secret", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.mfa.SavePendingSecret(r.Context(), claims.ID, secret); err != nil {
+		if errors.Is(err, mfa.ErrAlreadyConfirmed) {
+			http.Error(w, "mfa is already enabled", http.StatusConflict)
+			return
+		}
+		http.Error(w, "error enrolling mfa", http.StatusInternalServerError)
+		return
+	}
+
+	res := EnrollMFARes{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(h.cfg.Auth.MFAIssuer, claims.Email, secret),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmMFAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfa.GetMFA(r.Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			http.Error(w, "mfa enrollment not started", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error getting mfa", http.StatusInternalServerError)
+		return
+	}
+	if enrollment.ConfirmedAt != nil {
+		http.Error(w, "mfa is already enabled", http.StatusConflict)
+		return
+	}
+
+	step, ok := totp.Validate(enrollment.Secret, req.Code, time.Now())
+	if !ok {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		http.Error(w, "error generating recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.mfa.ConfirmMFA(r.Context(), claims.ID, step, hashes); err != nil {
+		if errors.Is(err, mfa.ErrAlreadyConfirmed) {
+			http.Error(w, "mfa is already enabled", http.StatusConflict)
+			return
+		}
+		http.Error(w, "error confirming mfa", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ConfirmMFARes{RecoveryCodes: codes})
+}
+
+// VerifyMFA exchanges the mfa_pending challenge from LoginUser plus a TOTP
+// or recovery code for a real token pair.
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req VerifyMFAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MFAToken == "" {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.TokenMaker.VerifyToken(req.MFAToken)
+	if err != nil || claims.Purpose != token.PurposeMFAPending {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+
+	used, err := h.Revocations.IsRevoked(r.Context(), claims)
+	if err != nil {
+		http.Error(w, "error checking mfa token", http.StatusInternalServerError)
+		return
+	}
+	if used {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+
+	ip, key := utils.ClientIP(r), accountKey(claims.ID)
+	if h.loginBlocked(w, r, ipKey(ip), key) {
+		return
+	}
+
+	if err := h.checkSecondFactor(r.Context(), claims.ID, req); err != nil {
+		if errors.Is(err, mfa.ErrCodeRejected) || errors.Is(err, mfa.ErrNotEnrolled) {
+			h.recordLoginFailure(r.Context(), ip, &claims.ID, key)
+			http.Error(w, "invalid code", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "error verifying mfa", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Revocations.Revoke(r.Context(), claims); err != nil {
+		http.Error(w, "error completing mfa", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.repository.GetUserByIdentifier(r.Context(), claims.ID.String())
+	if err != nil {
+		http.Error(w, "error getting user", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeLogin(w, r, user)
+}
+
+func (h *Handler) checkSecondFactor(ctx context.Context, userID uuid.UUID, req VerifyMFAReq) error {
+	if req.RecoveryCode != "" {
+		return h.mfa.UseRecoveryCode(ctx, userID, token.HashOpaqueToken(normalizeRecoveryCode(req.RecoveryCode)))
+	}
+
+	enrollment, err := h.mfa.GetMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enrollment.ConfirmedAt == nil {
+		return mfa.ErrNotEnrolled
+	}
+
+	step, ok := totp.Validate(enrollment.Secret, req.Code, time.Now())
+	if !ok {
+		return mfa.ErrCodeRejected
+	}
+	return h.mfa.UseStep(ctx, userID, step)
+}
+
+func (h *Handler) mfaRequired(ctx context.Context, userID uuid.UUID) (bool, error) {
+	enrollment, err := h.mfa.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.ConfirmedAt != nil, nil
+}
+
+func (h *Handler) writeMFAChallenge(w http.ResponseWriter, user *domains.User) {
+	challenge, claims, err := h.TokenMaker.CreateToken(user.ID, user.UserName, user.Email, mfaChallengeDuration,
+		token.WithPurpose(token.PurposeMFAPending),
+	)
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+
+	res := MFAChallengeRes{
+		MFARequired:       true,
+		MFAToken:          challenge,
+		MFATokenExpiresAt: claims.ExpiresAt.Time,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx along with the
+// hashes that get stored.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, token.HashOpaqueToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
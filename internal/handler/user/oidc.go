@@ -0,0 +1,287 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/identity"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
+	"github.com/bariscan97/clean-rest-architecture/pkg/oidc"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+const (
+	maxUsernameLength = 100
+
+	// oidcStateCookie ties a login to the browser that started it, so a
+	// callback URL cannot be replayed in someone else's browser.
+	oidcStateCookie = "oidc_state"
+)
+
+var (
+	errOIDCNoEmail         = errors.New("identity provider did not return an email address")
+	errOIDCEmailUnverified = errors.New("an account with this email already exists")
+
+	usernameDisallowed = regexp.MustCompile(`[^a-z0-9_.-]+`)
+)
+
+// StartOIDCLogin redirects the browser to the provider's authorization
+// endpoint with a fresh state, nonce and PKCE challenge.
+func (h *Handler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oidcProviders.Get(chi.URLParam(r, "provider"))
+	if !ok {
+		http.Error(w, "unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	state, err := token.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "error starting login", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := token.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "error starting login", http.StatusInternalServerError)
+		return
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		http.Error(w, "error starting login", http.StatusInternalServerError)
+		return
+	}
+
+	stateHash := token.HashOpaqueToken(state)
+	expiresAt := time.Now().Add(h.cfg.Auth.OIDC.StateTTL)
+	if err := h.identities.SaveLoginState(r.Context(), &domains.OIDCLoginState{
+		StateHash:    stateHash,
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    expiresAt,
+	}); err != nil {
+		http.Error(w, "error starting login", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		zap.L().Error("error building oidc authorization url", zap.String("provider", provider.Name()), zap.Error(err))
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	// The callback lives below this path, so the cookie is sent back with it.
+	h.setOIDCStateCookie(w, r.URL.Path, stateHash, expiresAt)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback finishes the flow started by StartOIDCLogin and signs the
+// user in exactly like LoginUser does, including the MFA challenge.
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oidcProviders.Get(chi.URLParam(r, "provider"))
+	if !ok {
+		http.Error(w, "unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "identity provider returned an error: "+e, http.StatusUnauthorized)
+		return
+	}
+	if q.Get("state") == "" || q.Get("code") == "" {
+		http.Error(w, "state and code are required", http.StatusBadRequest)
+		return
+	}
+
+	// Only the browser that started the login may finish it.
+	stateHash := token.HashOpaqueToken(q.Get("state"))
+	startPath := strings.TrimSuffix(r.URL.Path, "/callback")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(stateHash)) != 1 {
+		http.Error(w, "invalid or expired login state", http.StatusBadRequest)
+		return
+	}
+	h.setOIDCStateCookie(w, startPath, "", time.Unix(0, 0))
+
+	state, err := h.identities.ConsumeLoginState(r.Context(), provider.Name(), stateHash)
+	if err != nil {
+		if errors.Is(err, identity.ErrStateInvalid) {
+			http.Error(w, "invalid or expired login state", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error completing login", http.StatusInternalServerError)
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), q.Get("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		zap.L().Warn("oidc code exchange failed", zap.String("provider", provider.Name()), zap.Error(err))
+		http.Error(w, "could not verify identity", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.resolveOIDCUser(r.Context(), provider.Name(), claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCNoEmail):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, errOIDCEmailUnverified):
+			http.Error(w, "an account with this email already exists; sign in and verify your email first", http.StatusConflict)
+		default:
+			http.Error(w, "error completing login", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	mfaRequired, err := h.mfaRequired(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "error checking mfa", http.StatusInternalServerError)
+		return
+	}
+	if mfaRequired {
+		h.writeMFAChallenge(w, user)
+		return
+	}
+
+	h.writeLogin(w, r, user)
+}
+
+// setOIDCStateCookie stores the hash of a login's state in the browser
+// until expiresAt; an empty value clears it. SameSite=Lax still sends it on
+// the provider's top-level redirect back to the callback.
+func (h *Handler) setOIDCStateCookie(w http.ResponseWriter, path string, stateHash string, expiresAt time.Time) {
+	c := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateHash,
+		Path:     path,
+		Expires:  expiresAt,
+		Secure:   h.cfg.Auth.Cookie.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if stateHash == "" {
+		c.MaxAge = -1
+	}
+	http.SetCookie(w, c)
+}
+
+// resolveOIDCUser maps a provider identity to a local user. Known
+// identities sign straight in. Otherwise an existing user with the same
+// email is linked, but only when both the provider and the local account
+// have verified the address: anyone can register an unverified account
+// under someone else's email and would keep its password after the link.
+// Without a match a new user is created.
+func (h *Handler) resolveOIDCUser(ctx context.Context, provider string, claims *oidc.Claims) (*domains.User, error) {
+	linked, err := h.identities.UseIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return h.repository.GetUserByIdentifier(ctx, linked.UserID.String())
+	}
+	if !errors.Is(err, identity.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" {
+		return nil, errOIDCNoEmail
+	}
+
+	user, err := h.repository.GetUserByIdentifier(ctx, claims.Email)
+	switch {
+	case err == nil && user.Email == claims.Email:
+		if !claims.EmailVerified || user.EmailVerifiedAt == nil {
+			return nil, errOIDCEmailUnverified
+		}
+	case err == nil, errors.Is(err, repo.ErrUserNotFound):
+		user, err = h.createOIDCUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := h.identities.CreateIdentity(ctx, &domains.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+
+	if claims.EmailVerified && user.EmailVerifiedAt == nil {
+		if err := h.repository.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.repository.GetUserByIdentifier(ctx, user.ID.String())
+}
+
+// createOIDCUser registers a user who has only ever signed in through a
+// provider. The password is random and never disclosed; the user can set a
+// real one through the reset flow.
+func (h *Handler) createOIDCUser(ctx context.Context, claims *oidc.Claims) (*domains.User, error) {
+	username, err := h.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	random, err := token.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := h.passwords.Hash(random)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.repository.CreateUser(ctx, &domains.User{
+		UserName: username,
+		Email:    claims.Email,
+		Password: hashed,
+	})
+}
+
+// availableUsername derives a username from the provider's preferred
+// username or the email's local part and adds a random suffix when it is
+// already taken.
+func (h *Handler) availableUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Trim(usernameDisallowed.ReplaceAllString(strings.ToLower(base), ""), ".-")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > maxUsernameLength-9 {
+		base = base[:maxUsernameLength-9]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		_, err := h.repository.GetUserByIdentifier(ctx, candidate)
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		suffix, err := token.GenerateOpaqueToken()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "-" + strings.ToLower(suffix[:8])
+	}
+	return "", errors.New("could not find a free username")
+}
@@ -0,0 +1,505 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/identity"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/mfa"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/refresh"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/session"
+	repo "github.com/bariscan97/clean-rest-architecture/internal/repository/user"
+	"github.com/bariscan97/clean-rest-architecture/pkg/authcookie"
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/bariscan97/clean-rest-architecture/pkg/oidc"
+	"github.com/bariscan97/clean-rest-architecture/pkg/oidc/oidctest"
+	"github.com/bariscan97/clean-rest-architecture/pkg/password"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testProvider    = "test"
+	testAppURL      = "http://app.test"
+	testCallbackURL = testAppURL + "/auth/oidc/test/callback"
+)
+
+// memoryUsers mirrors the user repository queries the login flow runs.
+// The embedded interface is nil, so any other call panics.
+type memoryUsers struct {
+	repo.IUserRepository
+
+	mu    sync.Mutex
+	users []*domains.User
+}
+
+func (s *memoryUsers) add(u *domains.User) *domains.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u.ID = uuid.New()
+	s.users = append(s.users, u)
+	copied := *u
+	return &copied
+}
+
+func (s *memoryUsers) CreateUser(ctx context.Context, u *domains.User) (*domains.User, error) {
+	return s.add(&domains.User{UserName: u.UserName, Email: u.Email, Password: u.Password}), nil
+}
+
+func (s *memoryUsers) GetUserByIdentifier(ctx context.Context, identifier string) (*domains.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == identifier || u.UserName == identifier || u.ID.String() == identifier {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for identifier: %s", repo.ErrUserNotFound, identifier)
+}
+
+func (s *memoryUsers) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.ID == userID && u.Email == email {
+			if u.EmailVerifiedAt == nil {
+				now := time.Now()
+				u.EmailVerifiedAt = &now
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no unverified user %s with that email", userID)
+}
+
+func (s *memoryUsers) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.users)
+}
+
+// memoryIdentities keeps linked identities and login states in memory.
+type memoryIdentities struct {
+	mu         sync.Mutex
+	identities map[string]*domains.UserIdentity
+	states     map[string]*domains.OIDCLoginState
+}
+
+func newMemoryIdentities() *memoryIdentities {
+	return &memoryIdentities{
+		identities: map[string]*domains.UserIdentity{},
+		states:     map[string]*domains.OIDCLoginState{},
+	}
+}
+
+func (s *memoryIdentities) UseIdentity(ctx context.Context, provider string, subject string) (*domains.UserIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.identities[provider+"|"+subject]
+	if !ok {
+		return nil, identity.ErrIdentityNotFound
+	}
+	return i, nil
+}
+
+func (s *memoryIdentities) CreateIdentity(ctx context.Context, i *domains.UserIdentity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities[i.Provider+"|"+i.Subject] = i
+	return nil
+}
+
+func (s *memoryIdentities) SaveLoginState(ctx context.Context, state *domains.OIDCLoginState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.StateHash] = state
+	return nil
+}
+
+func (s *memoryIdentities) ConsumeLoginState(ctx context.Context, provider string, stateHash string) (*domains.OIDCLoginState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[stateHash]
+	if !ok || state.Provider != provider || time.Now().After(state.ExpiresAt) {
+		return nil, identity.ErrStateInvalid
+	}
+	delete(s.states, stateHash)
+	return state, nil
+}
+
+// onlyState returns the single pending login state.
+func (s *memoryIdentities) onlyState(t *testing.T) *domains.OIDCLoginState {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.states) != 1 {
+		t.Fatalf("%d pending login states, want 1", len(s.states))
+	}
+	for _, state := range s.states {
+		return state
+	}
+	return nil
+}
+
+func (s *memoryIdentities) linked(subject string) (*domains.UserIdentity, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.identities[testProvider+"|"+subject]
+	return i, ok
+}
+
+type noMFA struct{ mfa.IMFARepository }
+
+func (noMFA) GetMFA(ctx context.Context, userID uuid.UUID) (*domains.MFA, error) {
+	return nil, mfa.ErrNotEnrolled
+}
+
+type memorySessions struct{ session.ISessionRepository }
+
+func (memorySessions) CreateSession(ctx context.Context, s *domains.Session) (*domains.Session, error) {
+	created := *s
+	created.ID = uuid.New()
+	return &created, nil
+}
+
+type memoryRefreshTokens struct {
+	refresh.IRefreshTokenRepository
+}
+
+func (memoryRefreshTokens) CreateRefreshToken(ctx context.Context, t *domains.RefreshToken) (*domains.RefreshToken, error) {
+	created := *t
+	created.ID = uuid.New()
+	return &created, nil
+}
+
+type oidcEnv struct {
+	provider   *oidctest.Server
+	users      *memoryUsers
+	identities *memoryIdentities
+	router     chi.Router
+	// browser holds the cookies of the browser running the login.
+	browser http.CookieJar
+}
+
+func newOIDCEnv(t *testing.T) *oidcEnv {
+	t.Helper()
+
+	provider := oidctest.NewServer()
+	t.Cleanup(provider.Close)
+
+	cfg := &config.Config{}
+	cfg.Auth.OIDC.StateTTL = time.Minute
+	cfg.Password.Algorithm = password.AlgorithmBcrypt
+	cfg.Password.BcryptCost = bcrypt.MinCost
+
+	hasher, err := password.NewHasher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := oidc.NewProvider(testProvider, provider.ProviderConfig(testCallbackURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := oidc.NewRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.Register(p)
+
+	env := &oidcEnv{
+		provider:   provider,
+		users:      &memoryUsers{},
+		identities: newMemoryIdentities(),
+		browser:    newBrowser(t),
+	}
+	h := NewUserHandler(Deps{
+		Config:        cfg,
+		Users:         env.users,
+		RefreshTokens: memoryRefreshTokens{},
+		Sessions:      memorySessions{},
+		MFA:           noMFA{},
+		Passwords:     hasher,
+		Identities:    env.identities,
+		OIDCProviders: registry,
+		TokenMaker:    token.NewJWTMaker("oidc-test-secret"),
+		Cookies:       authcookie.NewManager(cfg),
+	})
+
+	r := chi.NewRouter()
+	r.Get("/auth/oidc/{provider}", h.StartOIDCLogin)
+	r.Get("/auth/oidc/{provider}/callback", h.OIDCCallback)
+	env.router = r
+	return env
+}
+
+func newBrowser(t *testing.T) http.CookieJar {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jar
+}
+
+// serve handles a GET to the app as sent from browser and stores the
+// cookies it sets.
+func (e *oidcEnv) serve(browser http.CookieJar, target string) *httptest.ResponseRecorder {
+	u, _ := url.Parse(testAppURL + target)
+	r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+	for _, c := range browser.Cookies(u) {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, r)
+	browser.SetCookies(u, w.Result().Cookies())
+	return w
+}
+
+// start begins a login and returns the provider's authorization URL.
+func (e *oidcEnv) start(t *testing.T) *url.URL {
+	t.Helper()
+	w := e.serve(e.browser, "/auth/oidc/"+testProvider)
+	if w.Code != http.StatusFound {
+		t.Fatalf("start login: status %d: %s", w.Code, w.Body)
+	}
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authURL
+}
+
+// authorize follows authURL at the provider and returns the callback query
+// it redirects back with.
+func (e *oidcEnv) authorize(t *testing.T, authURL *url.URL) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", res.StatusCode)
+	}
+	callback, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback.Query()
+}
+
+func (e *oidcEnv) callback(q url.Values) *httptest.ResponseRecorder {
+	return e.callbackIn(e.browser, q)
+}
+
+func (e *oidcEnv) callbackIn(browser http.CookieJar, q url.Values) *httptest.ResponseRecorder {
+	return e.serve(browser, "/auth/oidc/"+testProvider+"/callback?"+q.Encode())
+}
+
+// login runs the whole flow and returns the callback response.
+func (e *oidcEnv) login(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	return e.callback(e.authorize(t, e.start(t)))
+}
+
+func decodeLogin(t *testing.T, w *httptest.ResponseRecorder) LoginUserRes {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", w.Code, w.Body)
+	}
+	var res LoginUserRes
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.AccessToken == "" || res.RefreshToken == "" {
+		t.Fatalf("login response without tokens: %+v", res)
+	}
+	return res
+}
+
+func TestOIDCLoginUsesPKCE(t *testing.T) {
+	env := newOIDCEnv(t)
+
+	authURL := env.start(t)
+	q := authURL.Query()
+	state := env.identities.onlyState(t)
+
+	if q.Get("code_challenge_method") != "S256" {
+		t.Errorf("code_challenge_method = %q, want S256", q.Get("code_challenge_method"))
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge") != oidc.CodeChallengeS256(state.CodeVerifier) {
+		t.Errorf("code_challenge = %q, want the S256 challenge of the stored verifier", q.Get("code_challenge"))
+	}
+	if q.Get("code_challenge") == state.CodeVerifier {
+		t.Error("the verifier was sent to the provider")
+	}
+	if state.StateHash != token.HashOpaqueToken(q.Get("state")) {
+		t.Error("stored state hash does not match the state sent to the provider")
+	}
+	if q.Get("nonce") != state.Nonce {
+		t.Errorf("nonce = %q, want the stored nonce", q.Get("nonce"))
+	}
+
+	// A verifier that does not match the challenge is refused at the
+	// provider's token endpoint.
+	callback := env.authorize(t, authURL)
+	state.CodeVerifier = "not-the-original-verifier"
+	if w := env.callback(callback); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong verifier: status %d, want 401", w.Code)
+	}
+	if env.users.count() != 0 {
+		t.Error("user created despite the failed exchange")
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	env := newOIDCEnv(t)
+
+	callback := env.authorize(t, env.start(t))
+	forged := url.Values{"code": {callback.Get("code")}, "state": {"forged-state"}}
+	if w := env.callback(forged); w.Code != http.StatusBadRequest {
+		t.Errorf("forged state: status %d, want 400", w.Code)
+	}
+
+	decodeLogin(t, env.callback(callback))
+
+	// The state is used once; replaying the callback fails.
+	if w := env.callback(callback); w.Code != http.StatusBadRequest {
+		t.Errorf("replayed state: status %d, want 400", w.Code)
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	env := newOIDCEnv(t)
+
+	// An attacker starts a login and hands the callback URL to a victim.
+	callback := env.authorize(t, env.start(t))
+
+	victim := newBrowser(t)
+	if w := env.callbackIn(victim, callback); w.Code != http.StatusBadRequest {
+		t.Errorf("callback without the state cookie: status %d, want 400", w.Code)
+	}
+
+	// A victim in the middle of their own login has a cookie for a
+	// different state.
+	env.serve(victim, "/auth/oidc/"+testProvider)
+	if w := env.callbackIn(victim, callback); w.Code != http.StatusBadRequest {
+		t.Errorf("callback with another login's cookie: status %d, want 400", w.Code)
+	}
+	if env.users.count() != 0 {
+		t.Error("user created from a callback in the wrong browser")
+	}
+
+	// The state survives the failed attempts for the browser that owns it,
+	// and its cookie is cleared once the login is done.
+	decodeLogin(t, env.callback(callback))
+	u, _ := url.Parse(testCallbackURL)
+	if cookies := env.browser.Cookies(u); len(cookies) != 0 {
+		t.Errorf("cookies after login = %v, want none", cookies)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	env := newOIDCEnv(t)
+
+	callback := env.authorize(t, env.start(t))
+	// The provider signs the nonce it was sent; the one stored with the
+	// state no longer matches it.
+	env.identities.onlyState(t).Nonce = "another-nonce"
+
+	if w := env.callback(callback); w.Code != http.StatusUnauthorized {
+		t.Errorf("nonce mismatch: status %d, want 401", w.Code)
+	}
+	if env.users.count() != 0 {
+		t.Error("user created despite the nonce mismatch")
+	}
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	env := newOIDCEnv(t)
+	env.provider.SetUser(oidctest.User{
+		Subject:       "new-subject",
+		Email:         "newcomer@example.com",
+		EmailVerified: true,
+		Name:          "Newcomer",
+	})
+
+	res := decodeLogin(t, env.login(t))
+	if res.User.Email != "newcomer@example.com" || res.User.UserName != "newcomer" || !res.User.EmailVerified {
+		t.Errorf("user = %+v, want a verified newcomer", res.User)
+	}
+	linked, ok := env.identities.linked("new-subject")
+	if !ok || linked.UserID != res.User.ID {
+		t.Fatalf("identity = %+v, want one linked to %s", linked, res.User.ID)
+	}
+
+	// The linked identity signs straight back in.
+	again := decodeLogin(t, env.login(t))
+	if again.User.ID != res.User.ID || env.users.count() != 1 {
+		t.Errorf("second login signed in %s with %d users, want %s and 1", again.User.ID, env.users.count(), res.User.ID)
+	}
+}
+
+func TestOIDCLoginLinksVerifiedAccount(t *testing.T) {
+	env := newOIDCEnv(t)
+	verifiedAt := time.Now().Add(-time.Hour)
+	existing := env.users.add(&domains.User{UserName: "alice", Email: "alice@example.com", EmailVerifiedAt: &verifiedAt})
+	env.provider.SetUser(oidctest.User{Subject: "alice-subject", Email: "alice@example.com", EmailVerified: true})
+
+	res := decodeLogin(t, env.login(t))
+	if res.User.ID != existing.ID {
+		t.Errorf("signed in %s, want the existing user %s", res.User.ID, existing.ID)
+	}
+	if linked, ok := env.identities.linked("alice-subject"); !ok || linked.UserID != existing.ID {
+		t.Errorf("identity = %+v, want one linked to %s", linked, existing.ID)
+	}
+	if env.users.count() != 1 {
+		t.Errorf("%d users, want 1", env.users.count())
+	}
+}
+
+func TestOIDCLoginRefusesUnverifiedLink(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name             string
+		localVerifiedAt  *time.Time
+		providerVerified bool
+	}{
+		{"provider email unverified", &verifiedAt, false},
+		{"local email unverified", nil, true},
+		{"both unverified", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOIDCEnv(t)
+			env.users.add(&domains.User{UserName: "bob", Email: "bob@example.com", EmailVerifiedAt: tt.localVerifiedAt})
+			env.provider.SetUser(oidctest.User{Subject: "bob-subject", Email: "bob@example.com", EmailVerified: tt.providerVerified})
+
+			if w := env.login(t); w.Code != http.StatusConflict {
+				t.Errorf("status %d, want 409: %s", w.Code, w.Body)
+			}
+			if _, ok := env.identities.linked("bob-subject"); ok {
+				t.Error("identity linked to the unverified account")
+			}
+			if env.users.count() != 1 {
+				t.Errorf("%d users, want 1", env.users.count())
+			}
+		})
+	}
+}
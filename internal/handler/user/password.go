@@ -0,0 +1,130 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/passwordreset"
+	"github.com/bariscan97/clean-rest-architecture/pkg/mailer"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ForgotPassword always answers 202 so callers cannot tell whether the email
+// belongs to an account. The lookup and mail run in the background to keep
+// response times identical as well.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	go h.sendPasswordReset(context.WithoutCancel(r.Context()), req.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" || req.Password == "" {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	// Check the new password before spending the single-use token so the
+	// user can retry with the same link.
+	if errs := h.validatePassword("password", req.Password); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	hashed, err := h.passwords.Hash(req.Password)
+	if err != nil {
+		http.Error(w, "error hashing password", http.StatusInternalServerError)
+		return
+	}
+
+	userID, err := h.passwordResets.ResetPassword(r.Context(), token.HashOpaqueToken(req.Token), hashed)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrTokenInvalid) {
+			http.Error(w, "invalid or expired reset token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.revokeAllSessions(r.Context(), userID); err != nil {
+		http.Error(w, "error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rehashPassword upgrades a stored hash after a successful login. Failing to
+// do so is not fatal; the old hash keeps working.
+func (h *Handler) rehashPassword(ctx context.Context, userID uuid.UUID, plain string) {
+	hashed, err := h.passwords.Hash(plain)
+	if err != nil {
+		zap.L().Error("error rehashing password", zap.Error(err))
+		return
+	}
+	if err := h.repository.UpdateUserByID(ctx, userID, map[string]interface{}{"password": hashed}); err != nil {
+		zap.L().Error("error storing rehashed password", zap.String("userID", userID.String()), zap.Error(err))
+	}
+}
+
+// revokeAllSessions logs userID out everywhere: sessions and refresh tokens
+// are revoked and every access token issued so far is rejected by the auth
+// middleware.
+func (h *Handler) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := h.Sessions.RevokeUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := h.refreshTokens.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return h.Revocations.RevokeUser(ctx, userID, accessTokenDuration)
+}
+
+func (h *Handler) sendPasswordReset(ctx context.Context, email string) {
+	user, err := h.repository.GetUserByIdentifier(ctx, email)
+	if err != nil || user.Email != email {
+		return
+	}
+
+	plain, err := token.GenerateOpaqueToken()
+	if err != nil {
+		zap.L().Error("error generating password reset token", zap.Error(err))
+		return
+	}
+
+	if err := h.passwordResets.CreateResetToken(ctx, &domains.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: token.HashOpaqueToken(plain),
+		ExpiresAt: time.Now().Add(h.cfg.Auth.PasswordResetTTL),
+	}); err != nil {
+		zap.L().Error("error storing password reset token", zap.Error(err))
+		return
+	}
+
+	link := fmt.Sprintf("%s?token=%s", h.cfg.Auth.PasswordResetURL, url.QueryEscape(plain))
+
+	if err := h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nsomeone asked to reset the password of your account. If that was you, open the link below:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this mail.\n",
+			user.UserName, link, h.cfg.Auth.PasswordResetTTL),
+	}); err != nil {
+		zap.L().Error("error sending password reset email", zap.Error(err))
+	}
+}
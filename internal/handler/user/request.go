@@ -11,8 +11,53 @@ type LoginUserReq struct {
 	Password   string `json:"password"`
 }
 
+type UpdateUserRolesReq struct {
+	Roles []string `json:"roles"`
+}
+
 type UpdateUserReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	ImgUrl   string `json:"img_url"`
 }
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type CreatePersonalAccessTokenReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"`
+}
+
+type ImpersonateUserReq struct {
+	Reason string `json:"reason"`
+}
+
+type SuspendUserReq struct {
+	Reason string `json:"reason"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ConfirmMFAReq struct {
+	Code string `json:"code"`
+}
+
+type VerifyMFAReq struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
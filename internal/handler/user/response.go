@@ -11,10 +11,105 @@ type UserRes struct {
 	UserName string    `json:"username"`
 	Email    string    `json:"email"`
 	ImgUrl   string    `json:"img_url"`
+	Roles    []string  `json:"roles,omitempty"`
+
+	EmailVerified bool `json:"email_verified"`
+	// Status is only filled in for administrators.
+	Status string `json:"status,omitempty"`
+}
+
+// DataExportRes describes the caller's latest export. DownloadURL is only
+// set once the archive is ready and stops working at DownloadURLExpiresAt.
+type DataExportRes struct {
+	ID                   uuid.UUID  `json:"id"`
+	Status               string     `json:"status"`
+	CreateAt             time.Time  `json:"created_at"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	DownloadURL          string     `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
 }
 
+type DeactivationRes struct {
+	Status     string    `json:"status"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+// LoginUserRes carries the tokens in the body unless cookie authentication
+// is enabled, in which case they are only set as cookies and CSRFToken is
+// filled instead.
 type LoginUserRes struct {
-	AccessToken          string `json:"accessToken"`
-	User                 UserRes
+	AccessToken           string `json:"accessToken,omitempty"`
+	User                  UserRes
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"`
+}
+
+type RefreshTokenRes struct {
+	AccessToken           string    `json:"accessToken,omitempty"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"`
+}
+
+type PersonalAccessTokenRes struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreateAt   time.Time  `json:"create_at"`
+}
+
+type CreatePersonalAccessTokenRes struct {
+	Token string `json:"token"`
+	PersonalAccessTokenRes
+}
+
+type SessionRes struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreateAt   time.Time `json:"create_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+type ImpersonationRes struct {
+	AccessToken          string    `json:"accessToken"`
 	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	User                 UserRes   `json:"user"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationErrorRes struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
+}
+
+type VerifyEmailRes struct {
+	EmailVerified bool `json:"email_verified"`
+}
+
+type MFAChallengeRes struct {
+	MFARequired       bool      `json:"mfa_required"`
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+type EnrollMFARes struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type ConfirmMFARes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
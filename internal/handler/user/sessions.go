@@ -0,0 +1,82 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/session"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const maxUserAgentLength = 512
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.Sessions.ListSessions(r.Context(), claims.ID)
+	if err != nil {
+		http.Error(w, "error listing sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListSessionRes(sessions, claims.SessionID))
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currentUserID := caller.ID
+
+	if err := h.Sessions.RevokeSession(r.Context(), currentUserID, sessionID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error revoking session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs the user out on every device except the one
+// making the request.
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	if claims.SessionID == nil {
+		http.Error(w, "request is not bound to a session", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Sessions.RevokeOtherSessions(r.Context(), claims.ID, *claims.SessionID); err != nil {
+		http.Error(w, "error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
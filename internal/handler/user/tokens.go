@@ -0,0 +1,118 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/internal/repository/pat"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req CreatePersonalAccessTokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !domains.IsValidScope(scope) {
+			http.Error(w, "unknown scope: "+scope, http.StatusBadRequest)
+			return
+		}
+		// A token may only mint tokens that are no more powerful than itself.
+		if !claims.HasScope(scope) {
+			http.Error(w, "cannot grant scope: "+scope, http.StatusForbidden)
+			return
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	plain, err := token.GenerateOpaqueToken()
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+	plain = token.PersonalAccessTokenPrefix + plain
+
+	created, err := h.PersonalAccessTokens.CreateToken(r.Context(), &domains.PersonalAccessToken{
+		UserID:    claims.ID,
+		Name:      req.Name,
+		TokenHash: token.HashOpaqueToken(plain),
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+
+	res := CreatePersonalAccessTokenRes{
+		Token:                  plain,
+		PersonalAccessTokenRes: toPersonalAccessTokenRes(created),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) ListPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currentUserID := caller.ID
+
+	tokens, err := h.PersonalAccessTokens.ListTokens(r.Context(), currentUserID)
+	if err != nil {
+		http.Error(w, "error listing tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListPersonalAccessTokenRes(tokens))
+}
+
+func (h *Handler) RevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	caller, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+	currentUserID := caller.ID
+
+	if err := h.PersonalAccessTokens.RevokeToken(r.Context(), currentUserID, tokenID); err != nil {
+		if errors.Is(err, pat.ErrTokenNotFound) {
+			http.Error(w, "token not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error revoking token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const fieldRequired = "required"
+
+func (h *Handler) validatePassword(field string, plain string) []FieldError {
+	var errs []FieldError
+	for _, v := range h.passwordPolicy.Check(plain) {
+		errs = append(errs, FieldError{Field: field, Code: v.Code, Message: v.Message})
+	}
+	return errs
+}
+
+func (h *Handler) validateRegister(u RegisterUserReq) []FieldError {
+	var errs []FieldError
+	if strings.TrimSpace(u.UserName) == "" {
+		errs = append(errs, FieldError{Field: "username", Code: fieldRequired, Message: "username is required"})
+	}
+	if strings.TrimSpace(u.Email) == "" {
+		errs = append(errs, FieldError{Field: "email", Code: fieldRequired, Message: "email is required"})
+	}
+	return append(errs, h.validatePassword("password", u.Password)...)
+}
+
+// writeValidationErrors answers 422 with every field problem at once so
+// clients can show them next to the matching inputs.
+func writeValidationErrors(w http.ResponseWriter, errs []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(ValidationErrorRes{
+		Error:  "validation failed",
+		Fields: errs,
+	})
+}
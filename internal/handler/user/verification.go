@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/bariscan97/clean-rest-architecture/pkg/mailer"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+)
+
+var errVerificationThrottled = errors.New("verification email throttled")
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("token")
+	if raw == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.TokenMaker.VerifyToken(raw)
+	if err != nil || claims.Purpose != token.PurposeEmailVerification {
+		http.Error(w, "invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	used, err := h.Revocations.IsRevoked(r.Context(), claims)
+	if err != nil {
+		http.Error(w, "error checking verification token", http.StatusInternalServerError)
+		return
+	}
+	if used {
+		http.Error(w, "verification token has already been used", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repository.MarkEmailVerified(r.Context(), claims.ID, claims.Email); err != nil {
+		http.Error(w, "invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	// Verification tokens are single use; burn the jti like a logout does.
+	if err := h.Revocations.Revoke(r.Context(), claims); err != nil {
+		http.Error(w, "error completing verification", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(VerifyEmailRes{EmailVerified: true})
+}
+
+func (h *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	claims, ok := currentPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.repository.GetUserByIdentifier(r.Context(), claims.ID.String())
+	if err != nil {
+		http.Error(w, "error getting user", http.StatusInternalServerError)
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		http.Error(w, "email is already verified", http.StatusConflict)
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		if errors.Is(err, errVerificationThrottled) {
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", h.cfg.Auth.VerificationResendInterval.Seconds()))
+			http.Error(w, "verification email was sent recently, try again later", http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "error sending verification email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) sendVerificationEmail(ctx context.Context, user *domains.User) error {
+	ok, err := h.repository.ReserveVerificationEmail(ctx, user.ID, h.cfg.Auth.VerificationResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errVerificationThrottled
+	}
+
+	verificationToken, _, err := h.TokenMaker.CreateToken(user.ID, user.UserName, user.Email, h.cfg.Auth.EmailVerificationTTL,
+		token.WithPurpose(token.PurposeEmailVerification),
+	)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", h.cfg.App.PublicURL, url.QueryEscape(verificationToken))
+
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			user.UserName, link, h.cfg.Auth.EmailVerificationTTL),
+	})
+}
@@ -0,0 +1,279 @@
+package dataexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrExportNotFound   = errors.New("data export not found")
+	ErrExportInProgress = errors.New("a data export is already in progress")
+	ErrNoPendingExport  = errors.New("no pending data export")
+)
+
+type IDataExportRepository interface {
+	CreateExport(ctx context.Context, userID uuid.UUID) (*domains.DataExport, error)
+	GetExport(ctx context.Context, id uuid.UUID) (*domains.DataExport, error)
+	GetLatestExport(ctx context.Context, userID uuid.UUID) (*domains.DataExport, error)
+	ClaimExport(ctx context.Context, staleAfter time.Duration) (*domains.DataExport, error)
+	CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error
+	FailExport(ctx context.Context, id uuid.UUID, reason string) error
+	DeleteExpiredExports(ctx context.Context) ([]string, error)
+	CollectUserData(ctx context.Context, userID uuid.UUID) ([]*domains.ExportTable, error)
+}
+
+type dataExportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewDataExportRepository(pool *pgxpool.Pool) IDataExportRepository {
+	return &dataExportRepository{pool: pool}
+}
+
+const exportColumns = `id, user_id, status, blob_key, error, started_at, completed_at, expires_at, created_at`
+
+func scanExport(row pgx.Row) (*domains.DataExport, error) {
+	var e domains.DataExport
+	if err := row.Scan(
+		&e.ID,
+		&e.UserID,
+		&e.Status,
+		&e.BlobKey,
+		&e.Error,
+		&e.StartedAt,
+		&e.CompletedAt,
+		&e.ExpiresAt,
+		&e.CreateAt,
+	); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CreateExport queues an export for userID. It fails with
+// ErrExportInProgress while an earlier one is still pending or running.
+func (r *dataExportRepository) CreateExport(ctx context.Context, userID uuid.UUID) (*domains.DataExport, error) {
+	query := `
+		INSERT INTO data_exports (user_id)
+		VALUES ($1)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING ` + exportColumns
+	e, err := scanExport(r.pool.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportInProgress
+		}
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+	return e, nil
+}
+
+func (r *dataExportRepository) GetExport(ctx context.Context, id uuid.UUID) (*domains.DataExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM data_exports WHERE id = $1 AND user_id IS NOT NULL`
+	e, err := scanExport(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	return e, nil
+}
+
+func (r *dataExportRepository) GetLatestExport(ctx context.Context, userID uuid.UUID) (*domains.DataExport, error) {
+	query := `
+		SELECT ` + exportColumns + `
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	e, err := scanExport(r.pool.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	return e, nil
+}
+
+// ClaimExport marks the oldest pending export as running and returns it.
+// Exports left running for longer than staleAfter, e.g. by a worker that
+// crashed, are picked up again. Concurrent workers never claim the same row.
+func (r *dataExportRepository) ClaimExport(ctx context.Context, staleAfter time.Duration) (*domains.DataExport, error) {
+	query := `
+		UPDATE data_exports SET status = 'running', started_at = now()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE user_id IS NOT NULL
+			  AND (status = 'pending' OR (status = 'running' AND started_at < now() - $1::interval))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + exportColumns
+	e, err := scanExport(r.pool.QueryRow(ctx, query, staleAfter))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoPendingExport
+		}
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+	return e, nil
+}
+
+func (r *dataExportRepository) CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'ready', blob_key = $2, completed_at = now(), expires_at = $3
+		WHERE id = $1 AND status = 'running'
+	`
+	result, err := r.pool.Exec(ctx, query, id, blobKey, expiresAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrExportNotFound
+	}
+	return nil
+}
+
+func (r *dataExportRepository) FailExport(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'failed', error = $2, completed_at = now()
+		WHERE id = $1 AND status = 'running'
+	`
+	_, err := r.pool.Exec(ctx, query, id, reason)
+	return err
+}
+
+// DeleteExpiredExports removes expired exports and those of purged accounts
+// and returns the blob keys that need deleting.
+func (r *dataExportRepository) DeleteExpiredExports(ctx context.Context) ([]string, error) {
+	query := `
+		DELETE FROM data_exports
+		WHERE expires_at < now() OR user_id IS NULL
+		RETURNING blob_key
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key *string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// exportQueries lists what ends up in an export, one dataset per entry.
+// Secrets such as password and token hashes or the TOTP seed are left out.
+var exportQueries = []struct {
+	name  string
+	query string
+}{
+	{"profile", `
+		SELECT id::text AS id, username, email, img_url, roles, email_verified_at,
+		       deactivated_at, purge_after, suspended_at, suspension_reason, updated_at, created_at
+		FROM users WHERE id = $1`},
+	{"posts", `
+		SELECT id::text AS id, title, content, created_at, updated_at
+		FROM posts WHERE user_id = $1 AND parent_id IS NULL
+		ORDER BY created_at`},
+	{"comments", `
+		SELECT id::text AS id, parent_id::text AS parent_id, title, content, created_at, updated_at
+		FROM posts WHERE user_id = $1 AND parent_id IS NOT NULL
+		ORDER BY created_at`},
+	{"reactions", `
+		SELECT post_id::text AS post_id, kind, created_at
+		FROM post_reactions WHERE user_id = $1
+		ORDER BY created_at`},
+	{"sessions", `
+		SELECT id::text AS id, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM sessions WHERE user_id = $1
+		ORDER BY created_at`},
+	{"personal_access_tokens", `
+		SELECT id::text AS id, name, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM personal_access_tokens WHERE user_id = $1
+		ORDER BY created_at`},
+	{"linked_identities", `
+		SELECT provider, subject, email, last_login_at, created_at
+		FROM user_identities WHERE user_id = $1
+		ORDER BY created_at`},
+	{"mfa", `
+		SELECT confirmed_at, created_at
+		FROM user_mfa WHERE user_id = $1`},
+	{"impersonation_audit", `
+		SELECT event, method, path, reason, created_at
+		FROM impersonation_audit WHERE user_id = $1
+		ORDER BY created_at`},
+	{"data_exports", `
+		SELECT id::text AS id, status, completed_at, expires_at, created_at
+		FROM data_exports WHERE user_id = $1
+		ORDER BY created_at`},
+}
+
+// CollectUserData reads every per-user record for an export in a single
+// repeatable-read transaction so the datasets are consistent with each
+// other.
+func (r *dataExportRepository) CollectUserData(ctx context.Context, userID uuid.UUID) ([]*domains.ExportTable, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tables := make([]*domains.ExportTable, 0, len(exportQueries))
+	for _, q := range exportQueries {
+		table, err := collectTable(ctx, tx, q.name, q.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", q.name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func collectTable(ctx context.Context, tx pgx.Tx, name string, query string, userID uuid.UUID) (*domains.ExportTable, error) {
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	table := &domains.ExportTable{Name: name}
+	for _, field := range rows.FieldDescriptions() {
+		table.Columns = append(table.Columns, field.Name)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		table.Rows = append(table.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
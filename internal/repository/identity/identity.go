@@ -0,0 +1,107 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrStateInvalid     = errors.New("oidc login state is invalid or expired")
+)
+
+type IIdentityRepository interface {
+	UseIdentity(ctx context.Context, provider string, subject string) (*domains.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *domains.UserIdentity) error
+	SaveLoginState(ctx context.Context, state *domains.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, provider string, stateHash string) (*domains.OIDCLoginState, error)
+}
+
+type identityRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdentityRepository(pool *pgxpool.Pool) IIdentityRepository {
+	return &identityRepository{pool: pool}
+}
+
+// UseIdentity looks up a linked identity and records the login.
+func (r *identityRepository) UseIdentity(ctx context.Context, provider string, subject string) (*domains.UserIdentity, error) {
+	query := `
+		UPDATE user_identities SET last_login_at = now()
+		WHERE provider = $1 AND subject = $2
+		RETURNING id, user_id, provider, subject, email, last_login_at, created_at
+	`
+	var i domains.UserIdentity
+	if err := r.pool.QueryRow(ctx, query, provider, subject).Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Subject,
+		&i.Email,
+		&i.LastLoginAt,
+		&i.CreateAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	return &i, nil
+}
+
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *domains.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, now())
+	`
+	if _, err := r.pool.Exec(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email); err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	return nil
+}
+
+func (r *identityRepository) SaveLoginState(ctx context.Context, state *domains.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := r.pool.Exec(ctx, query, state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to save oidc login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState deletes and returns the state so each one can complete
+// at most one login. Expired states are cleaned up on the way.
+func (r *identityRepository) ConsumeLoginState(ctx context.Context, provider string, stateHash string) (*domains.OIDCLoginState, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at <= now()`); err != nil {
+		return nil, fmt.Errorf("failed to delete expired oidc login states: %w", err)
+	}
+
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND provider = $2
+		RETURNING state_hash, provider, nonce, code_verifier, expires_at, created_at
+	`
+	var s domains.OIDCLoginState
+	if err := r.pool.QueryRow(ctx, query, stateHash, provider).Scan(
+		&s.StateHash,
+		&s.Provider,
+		&s.Nonce,
+		&s.CodeVerifier,
+		&s.ExpiresAt,
+		&s.CreateAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStateInvalid
+		}
+		return nil, fmt.Errorf("failed to consume oidc login state: %w", err)
+	}
+	return &s, nil
+}
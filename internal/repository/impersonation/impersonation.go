@@ -0,0 +1,41 @@
+package impersonation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IImpersonationRepository interface {
+	RecordEvent(ctx context.Context, entry *domains.ImpersonationAuditEntry) error
+}
+
+type impersonationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImpersonationRepository(pool *pgxpool.Pool) IImpersonationRepository {
+	return &impersonationRepository{pool: pool}
+}
+
+func (r *impersonationRepository) RecordEvent(ctx context.Context, entry *domains.ImpersonationAuditEntry) error {
+	query := `
+		INSERT INTO impersonation_audit (token_id, actor_id, user_id, event, method, path, ip, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	if _, err := r.pool.Exec(ctx, query,
+		entry.TokenID,
+		entry.ActorID,
+		entry.UserID,
+		entry.Event,
+		entry.Method,
+		entry.Path,
+		entry.IP,
+		entry.Reason,
+	); err != nil {
+		return fmt.Errorf("failed to record impersonation event: %w", err)
+	}
+	return nil
+}
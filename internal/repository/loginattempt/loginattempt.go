@@ -0,0 +1,88 @@
+package loginattempt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ILoginAttemptRepository interface {
+	BlockedUntil(ctx context.Context, keys ...string) (*time.Time, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	Block(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	RecordLockout(ctx context.Context, event *domains.LockoutEvent) error
+}
+
+type loginAttemptRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(pool *pgxpool.Pool) ILoginAttemptRepository {
+	return &loginAttemptRepository{pool: pool}
+}
+
+// BlockedUntil returns the latest block still in force for any of keys, or
+// nil when none of them is blocked.
+func (r *loginAttemptRepository) BlockedUntil(ctx context.Context, keys ...string) (*time.Time, error) {
+	query := `
+		SELECT max(blocked_until)
+		FROM login_failures
+		WHERE key = ANY($1) AND blocked_until > now()
+	`
+	var until *time.Time
+	if err := r.pool.QueryRow(ctx, query, keys).Scan(&until); err != nil {
+		return nil, fmt.Errorf("failed to check login block: %w", err)
+	}
+	return until, nil
+}
+
+// RecordFailure bumps the failure counter for key and returns the new count.
+// Counters whose last failure is older than window start over.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_failures (key, failures, last_failed_at)
+		VALUES ($1, 1, now())
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+		        WHEN login_failures.last_failed_at < now() - $2::interval THEN 1
+		        ELSE login_failures.failures + 1
+		    END,
+		    last_failed_at = now()
+		RETURNING failures
+	`
+	var failures int
+	if err := r.pool.QueryRow(ctx, query, key, window).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return failures, nil
+}
+
+func (r *loginAttemptRepository) Block(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_failures SET blocked_until = $2 WHERE key = $1`
+	if _, err := r.pool.Exec(ctx, query, key, until); err != nil {
+		return fmt.Errorf("failed to block %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM login_failures WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to reset login failures for %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) RecordLockout(ctx context.Context, event *domains.LockoutEvent) error {
+	query := `
+		INSERT INTO lockout_events (key, user_id, ip, failures, locked_until)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := r.pool.Exec(ctx, query, event.Key, event.UserID, event.IP, event.Failures, event.LockedUntil); err != nil {
+		return fmt.Errorf("failed to record lockout event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotEnrolled      = errors.New("mfa is not enrolled")
+	ErrAlreadyConfirmed = errors.New("mfa is already confirmed")
+	ErrCodeRejected     = errors.New("mfa code rejected")
+)
+
+type IMFARepository interface {
+	GetMFA(ctx context.Context, userID uuid.UUID) (*domains.MFA, error)
+	SavePendingSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+}
+
+type mfaRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) IMFARepository {
+	return &mfaRepository{pool: pool}
+}
+
+func (r *mfaRepository) GetMFA(ctx context.Context, userID uuid.UUID) (*domains.MFA, error) {
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+	var m domains.MFA
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(
+		&m.UserID,
+		&m.Secret,
+		&m.ConfirmedAt,
+		&m.LastUsedStep,
+		&m.CreateAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotEnrolled
+		}
+		return nil, fmt.Errorf("failed to get mfa for user %s: %w", userID, err)
+	}
+	return &m, nil
+}
+
+// SavePendingSecret starts or restarts an enrollment. A confirmed enrollment
+// is never overwritten.
+func (r *mfaRepository) SavePendingSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = now(), last_used_step = NULL
+		WHERE user_mfa.confirmed_at IS NULL
+	`
+	result, err := r.pool.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyConfirmed
+	}
+	return nil
+}
+
+// ConfirmMFA activates the enrollment and replaces any recovery codes.
+func (r *mfaRepository) ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE user_mfa SET confirmed_at = now(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to confirm mfa: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyConfirmed
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep records a successfully validated TOTP step. Steps at or before the
+// last used one are rejected so a code cannot be replayed.
+func (r *mfaRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_mfa SET last_used_step = $2
+		WHERE user_id = $1 AND (last_used_step IS NULL OR last_used_step < $2)
+	`
+	result, err := r.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record mfa step: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCodeRejected
+	}
+	return nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = now()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := r.pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCodeRejected
+	}
+	return nil
+}
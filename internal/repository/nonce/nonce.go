@@ -0,0 +1,43 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/reqsign"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type nonceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewNonceRepository(pool *pgxpool.Pool) reqsign.NonceStore {
+	return &nonceRepository{pool: pool}
+}
+
+// Remember inserts the nonce and reports whether it was new. An expired row
+// left behind by the sweeper does not count as a replay.
+func (r *nonceRepository) Remember(ctx context.Context, keyID string, nonce string, expiresAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO request_nonces (key_id, nonce, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key_id, nonce) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at
+		WHERE request_nonces.expires_at <= now()
+	`
+	result, err := r.pool.Exec(ctx, query, keyID, nonce, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to store request nonce: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *nonceRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM request_nonces WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired request nonces: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,80 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTokenInvalid = errors.New("password reset token is invalid or expired")
+
+type IPasswordResetRepository interface {
+	CreateResetToken(ctx context.Context, token *domains.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (uuid.UUID, error)
+}
+
+type passwordResetRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool) IPasswordResetRepository {
+	return &passwordResetRepository{pool: pool}
+}
+
+func (r *passwordResetRepository) CreateResetToken(ctx context.Context, token *domains.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := r.pool.Exec(ctx, query, token.UserID, token.TokenHash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword spends the reset token and sets passwordHash for its owner
+// in one transaction, so a failure leaves the token usable. Any other
+// outstanding reset tokens of that user are burned as well.
+func (r *passwordResetRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		UPDATE password_reset_tokens SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrTokenInvalid
+		}
+		return uuid.Nil, fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE password_reset_tokens SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET password = $2, updated_at = now()
+		WHERE id = $1
+	`, userID, passwordHash); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,126 @@
+package pat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTokenNotFound = errors.New("personal access token not found")
+
+type IPersonalAccessTokenRepository interface {
+	CreateToken(ctx context.Context, token *domains.PersonalAccessToken) (*domains.PersonalAccessToken, error)
+	ListTokens(ctx context.Context, userID uuid.UUID) ([]*domains.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, userID uuid.UUID, tokenID uuid.UUID) error
+	UseToken(ctx context.Context, tokenHash string) (*domains.PersonalAccessTokenOwner, error)
+}
+
+type personalAccessTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPersonalAccessTokenRepository(pool *pgxpool.Pool) IPersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{pool: pool}
+}
+
+func (r *personalAccessTokenRepository) CreateToken(ctx context.Context, token *domains.PersonalAccessToken) (*domains.PersonalAccessToken, error) {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, name, scopes, expires_at, created_at
+	`
+	var t domains.PersonalAccessToken
+	if err := r.pool.QueryRow(ctx, query, token.UserID, token.Name, token.TokenHash, token.Scopes, token.ExpiresAt).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Scopes,
+		&t.ExpiresAt,
+		&t.CreateAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *personalAccessTokenRepository) ListTokens(ctx context.Context, userID uuid.UUID) ([]*domains.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*domains.PersonalAccessToken
+	for rows.Next() {
+		var t domains.PersonalAccessToken
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreateAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepository) RevokeToken(ctx context.Context, userID uuid.UUID, tokenID uuid.UUID) error {
+	query := `
+		UPDATE personal_access_tokens SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	result, err := r.pool.Exec(ctx, query, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token %s: %w", tokenID, err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// UseToken looks up an active token by hash and records that it was used.
+func (r *personalAccessTokenRepository) UseToken(ctx context.Context, tokenHash string) (*domains.PersonalAccessTokenOwner, error) {
+	query := `
+		UPDATE personal_access_tokens AS t SET last_used_at = now()
+		FROM users AS u
+		WHERE t.token_hash = $1
+		  AND t.user_id = u.id
+		  AND t.revoked_at IS NULL
+		  AND (t.expires_at IS NULL OR t.expires_at > now())
+		  AND u.deactivated_at IS NULL
+		  AND u.suspended_at IS NULL
+		RETURNING t.id, t.user_id, t.name, t.scopes, t.expires_at, t.last_used_at, t.created_at, u.username, u.email,
+		          u.email_verified_at IS NOT NULL
+	`
+	var t domains.PersonalAccessTokenOwner
+	if err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Scopes,
+		&t.ExpiresAt,
+		&t.LastUsedAt,
+		&t.CreateAt,
+		&t.UserName,
+		&t.Email,
+		&t.EmailVerified,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to look up personal access token: %w", err)
+	}
+	return &t, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"errors"
+	"strings"
+	"time"
 	"github.com/bariscan97/clean-rest-architecture/internal/domains"
 	"github.com/bariscan97/clean-rest-architecture/internal/utils"
 	"github.com/google/uuid"
@@ -11,11 +13,23 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+var ErrPostNotFound = errors.New("post not found")
+
 type IPostRepository interface {
 	ListPosts(ctx context.Context, userID *uuid.UUID, parentID *uuid.UUID, page int, limit int) ([]*domains.PostManyToMany, error)
+	ListPostsByCursor(ctx context.Context, userID *uuid.UUID, parentID *uuid.UUID, page domains.PageRequest) ([]*domains.PostManyToMany, bool, error)
 	CreatePost(ctx context.Context, parentID *uuid.UUID, userID uuid.UUID, post *domains.Post) (*domains.Post, error)
 	DeletePostByID(ctx context.Context, userID uuid.UUID, postID uuid.UUID) error
-    UpdatePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID, fields map[string]interface{}) error
+	DeleteAnyPostByID(ctx context.Context, postID uuid.UUID) error
+	UpdatePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID, update domains.PostUpdate) error
+	GetThread(ctx context.Context, rootID uuid.UUID, maxDepth int, limit int, after *domains.Cursor) ([]*domains.ThreadPost, error)
+	SearchPosts(ctx context.Context, search domains.PostSearch) ([]*domains.PostSearchResult, error)
+	ListPostsByTag(ctx context.Context, tag string, page domains.PageRequest) ([]*domains.PostManyToMany, bool, error)
+	SearchTags(ctx context.Context, prefix string, limit int) ([]*domains.Tag, error)
+	AddReaction(ctx context.Context, postID uuid.UUID, userID uuid.UUID, kind string) error
+	RemoveReaction(ctx context.Context, postID uuid.UUID, userID uuid.UUID, kind string) error
+	ListReactions(ctx context.Context, postID uuid.UUID, kind string, page domains.PageRequest) ([]*domains.Reaction, bool, error)
+	ViewerReactions(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID][]string, error)
 }
 
 type postRepository struct {
@@ -26,6 +40,44 @@ func NewUserRepository(pool *pgxpool.Pool) IPostRepository {
 	return &postRepository{pool: pool}
 }
 
+// postFilter builds the WHERE clause shared by the post listings. Posts of
+// accounts that are suspended or waiting to be purged are hidden until the
+// account is active again.
+func postFilter(userID *uuid.UUID, parentID *uuid.UUID) (string, []any) {
+	where := "WHERE u.deactivated_at IS NULL AND u.suspended_at IS NULL"
+	var params []any
+
+	if userID != nil {
+		params = append(params, *userID)
+		where += fmt.Sprintf(" AND p.user_id = $%d", len(params))
+	} else if parentID != nil {
+		params = append(params, *parentID)
+		where += fmt.Sprintf(" AND p.parent_id = $%d", len(params))
+	}
+
+	return where, params
+}
+
+const postListColumns = `
+	p.id, p.parent_id, p.user_id, u.username, u.img_url,
+	p.title, p.content, ` + postTagsColumn + `, ` + postReactionsColumn + `,
+	COALESCE(p.updated_at, p.created_at), p.created_at`
+
+// postTagsColumn selects the tag names of the post aliased p.
+const postTagsColumn = `ARRAY(
+		SELECT t.name FROM post_tags AS pt JOIN tags AS t ON t.id = pt.tag_id
+		WHERE pt.post_id = p.id ORDER BY t.name)`
+
+// postReactionsColumn reads the reaction counts of the post aliased p as a
+// JSON object keyed by kind. The counts include reactions of suspended and
+// deactivated users, which ListReactions leaves out; filtering them here
+// would mean counting post_reactions for every listed post.
+const postReactionsColumn = `COALESCE((
+		SELECT jsonb_object_agg(rc.kind, rc.count) FROM post_reaction_counts AS rc
+		WHERE rc.post_id = p.id AND rc.count > 0), '{}'::jsonb)`
+
+// ListPosts pages with LIMIT/OFFSET. It is kept for clients still sending
+// ?page=; ListPostsByCursor is stable while posts are being added.
 func (r *postRepository) ListPosts(
 	ctx context.Context,
 	userID *uuid.UUID,
@@ -42,41 +94,98 @@ func (r *postRepository) ListPosts(
 	}
 	offset := (page - 1) * limit
 
-	var (
-		condition string
-		params     []any
-	)
+	where, params := postFilter(userID, parentID)
+	index := len(params) + 1
 
-	index := 1
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM posts AS p
+        JOIN users AS u
+        ON u.id = p.user_id
+        %s
+        ORDER BY p.created_at DESC, p.id DESC
+        LIMIT $%d OFFSET $%d
+    `, postListColumns, where, index, index+1)
 
-	if userID != nil {
-		condition = fmt.Sprintf(" p.user_id = $%d", index)
-		params = append(params, *userID)
-		index++
-	}else if parentID != nil {
-		condition = fmt.Sprintf(" p.parent_id = $%d", index)
-		params = append(params, *parentID)
-		index++
-	}
+	params = append(params, limit, offset)
+
+	return r.queryPosts(ctx, query, params...)
+}
+
+// ListPostsByCursor returns up to page.Limit posts, newest first, next to
+// page.Cursor. hasMore reports whether further posts exist in the
+// direction requested.
+func (r *postRepository) ListPostsByCursor(
+	ctx context.Context,
+	userID *uuid.UUID,
+	parentID *uuid.UUID,
+	page domains.PageRequest,
+) ([]*domains.PostManyToMany, bool, error) {
+
+	where, params := postFilter(userID, parentID)
+	return r.listByCursor(ctx, "", where, params, page)
+}
+
+// ListPostsByTag pages through the posts tagged with tag like
+// ListPostsByCursor does.
+func (r *postRepository) ListPostsByTag(ctx context.Context, tag string, page domains.PageRequest) ([]*domains.PostManyToMany, bool, error) {
+	where, params := postFilter(nil, nil)
+	params = append(params, tag)
+	join := fmt.Sprintf(`
+        JOIN post_tags AS pt ON pt.post_id = p.id
+        JOIN tags AS t ON t.id = pt.tag_id AND t.name = $%d`, len(params))
+
+	return r.listByCursor(ctx, join, where, params, page)
+}
+
+func (r *postRepository) listByCursor(
+	ctx context.Context,
+	join string,
+	where string,
+	params []any,
+	page domains.PageRequest,
+) ([]*domains.PostManyToMany, bool, error) {
 
-	whereClause := ""
-	if condition != "" {
-		whereClause = "WHERE " + condition
+	// Forward walks towards older posts. Backward runs the query the other
+	// way round and the result is flipped afterwards.
+	cmp, order := "<", "DESC"
+	if page.Backward {
+		cmp, order = ">", "ASC"
 	}
+	if page.Cursor != nil {
+		params = append(params, page.Cursor.CreateAt, page.Cursor.ID)
+		where += fmt.Sprintf(" AND (p.created_at, p.id) %s ($%d, $%d)", cmp, len(params)-1, len(params))
+	}
+	params = append(params, page.Limit+1)
 
 	query := fmt.Sprintf(`
-        SELECT p.id, p.parent_id, p.user_id, u.username, u.img_url, 
-               p.title, p.content, p.update_at, p.create_at
+        SELECT %s
         FROM posts AS p
-        LEFT JOIN users AS u 
-        ON u.id = p.user_id
+        JOIN users AS u
+        ON u.id = p.user_id%s
         %s
-        ORDER BY p.create_at DESC
-        LIMIT $%d OFFSET $%d
-    `, whereClause, index, index+1)
+        ORDER BY p.created_at %s, p.id %s
+        LIMIT $%d
+    `, postListColumns, join, where, order, order, len(params))
 
-	params = append(params, limit, offset)
+	posts, err := r.queryPosts(ctx, query, params...)
+	if err != nil {
+		return nil, false, err
+	}
 
+	hasMore := len(posts) > page.Limit
+	if hasMore {
+		posts = posts[:page.Limit]
+	}
+	if page.Backward {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+	return posts, hasMore, nil
+}
+
+func (r *postRepository) queryPosts(ctx context.Context, query string, params ...any) ([]*domains.PostManyToMany, error) {
 	rows, err := r.pool.Query(ctx, query, params...)
 	if err != nil {
 		return nil, err
@@ -95,6 +204,8 @@ func (r *postRepository) ListPosts(
 			&p.UserImg,
 			&p.Title,
 			&p.Content,
+			&p.Tags,
+			&p.Reactions,
 			&p.UpdateAt,
 			&p.CreateAt,
 		); err != nil {
@@ -136,33 +247,78 @@ func (r *postRepository) GetUserPostsById(ctx context.Context, postID uuid.UUID)
 	return &post, nil
 }
 
-func (r *postRepository) UpdatePost(ctx context.Context, userID uuid.UUID, postID uuid.UUID, fields map[string]interface{}) error {
-	query, parameters := utils.BuildUpdateQueryMap("users", fields, map[string]interface{}{
+// UpdatePost applies update to a post owned by userID. Changing the content
+// or the explicit tags recomputes the post's tags in the same transaction.
+func (r *postRepository) UpdatePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID, update domains.PostUpdate) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var content string
+	if err := tx.QueryRow(ctx,
+		`SELECT content FROM posts WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		postID, userID,
+	).Scan(&content); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("failed to load post %s: %w", postID, err)
+	}
+
+	fields := map[string]interface{}{"updated_at": time.Now()}
+	if update.Title != nil {
+		fields["title"] = *update.Title
+	}
+	if update.Content != nil {
+		fields["content"] = *update.Content
+		content = *update.Content
+	}
+
+	query, parameters := utils.BuildUpdateQueryMap("posts", fields, map[string]interface{}{
 		"id": postID,
-		"user_id": userID,
 	})
-
-	result, err := r.pool.Exec(ctx, query, parameters...)
-	if err != nil {
+	if _, err := tx.Exec(ctx, query, parameters...); err != nil {
 		return err
 	}
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("no rows updated for postID: %s", postID)
+
+	if update.Content != nil || update.Tags != nil {
+		var explicit []string
+		if update.Tags != nil {
+			explicit = *update.Tags
+		} else if explicit, err = explicitTags(ctx, tx, postID); err != nil {
+			return err
+		}
+
+		tags, err := domains.MergePostTags(explicit, content)
+		if err != nil {
+			return err
+		}
+		if err := setPostTags(ctx, tx, postID, tags); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
+// CreatePost inserts post together with its tags.
 func (r *postRepository) CreatePost(ctx context.Context, parentID *uuid.UUID, userID uuid.UUID, post *domains.Post) (*domains.Post, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
 	query := `
         INSERT INTO posts(parent_id, user_id, title, content)
         VALUES ($1, $2, $3, $4)
-		RETURNING id, parent_id, user_id, title, content, update_at, create_at
+		RETURNING id, parent_id, user_id, title, content, COALESCE(updated_at, created_at), created_at
     `
 	var p domains.Post
 
-	if err := r.pool.QueryRow(ctx, query, post.ParentID, post.Title, post.Content).Scan(
+	if err := tx.QueryRow(ctx, query, parentID, userID, post.Title, post.Content).Scan(
 		&p.ID,
 		&p.ParentID,
 		&p.UserID,
@@ -174,9 +330,73 @@ func (r *postRepository) CreatePost(ctx context.Context, parentID *uuid.UUID, us
 		return nil, err
 	}
 
+	if err := setPostTags(ctx, tx, p.ID, post.Tags); err != nil {
+		return nil, err
+	}
+	p.Tags = post.Tags
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
+func explicitTags(ctx context.Context, tx pgx.Tx, postID uuid.UUID) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT t.name
+		FROM post_tags AS pt
+		JOIN tags AS t ON t.id = pt.tag_id
+		WHERE pt.post_id = $1 AND pt.explicit
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// setPostTags makes tags the complete set of tags on postID. Links that
+// stay are left alone so the per-tag counts only move for real changes.
+func setPostTags(ctx context.Context, tx pgx.Tx, postID uuid.UUID, tags []domains.PostTag) error {
+	names := make([]string, len(tags))
+	explicit := make([]bool, len(tags))
+	for i, t := range tags {
+		names[i], explicit[i] = t.Name, t.Explicit
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM post_tags AS pt
+		USING tags AS t
+		WHERE t.id = pt.tag_id AND pt.post_id = $1 AND NOT (t.name = ANY($2::text[]))
+	`, postID, names); err != nil {
+		return fmt.Errorf("failed to remove post tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	// Rows are touched in name order so that concurrent writers sharing
+	// tags lock them in the same order.
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ORDER BY 1
+		ON CONFLICT (name) DO NOTHING
+	`, names); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO post_tags (post_id, tag_id, explicit)
+		SELECT $1, t.id, x.explicit
+		FROM unnest($2::text[], $3::bool[]) AS x(name, explicit)
+		JOIN tags AS t ON t.name = x.name
+		ORDER BY t.name
+		ON CONFLICT (post_id, tag_id) DO UPDATE SET explicit = EXCLUDED.explicit
+	`, postID, names, explicit); err != nil {
+		return fmt.Errorf("failed to tag post: %w", err)
+	}
+	return nil
+}
+
 func (r *postRepository) DeletePostByID(ctx context.Context, userID uuid.UUID, postID uuid.UUID) error {
 	query := `DELETE FROM posts WHERE id = $1 and user_id = $2`
 	_, err := r.pool.Exec(ctx, query, postID, userID)
@@ -185,3 +405,364 @@ func (r *postRepository) DeletePostByID(ctx context.Context, userID uuid.UUID, p
 	}
 	return nil
 }
+
+func (r *postRepository) DeleteAnyPostByID(ctx context.Context, postID uuid.UUID) error {
+	query := `DELETE FROM posts WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post with id %s: %w", postID, err)
+	}
+	return nil
+}
+
+// GetThread loads rootID and its replies down to maxDepth levels in one
+// query. Each post gets at most limit replies, oldest first, plus one extra
+// that only signals there are more. after skips the root's replies up to
+// and including that position so the first level can be paged.
+func (r *postRepository) GetThread(ctx context.Context, rootID uuid.UUID, maxDepth int, limit int, after *domains.Cursor) ([]*domains.ThreadPost, error) {
+	var (
+		afterCreateAt *time.Time
+		afterID       *uuid.UUID
+	)
+	if after != nil {
+		afterCreateAt, afterID = &after.CreateAt, &after.ID
+	}
+
+	query := `
+		WITH RECURSIVE thread AS (
+			SELECT p.id, p.parent_id, p.user_id, p.title, p.content, p.updated_at, p.created_at,
+			       0 AS depth, 1::bigint AS position
+			FROM posts AS p
+			JOIN users AS u ON u.id = p.user_id
+			WHERE p.id = $1
+			  AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		  UNION ALL
+			SELECT c.id, c.parent_id, c.user_id, c.title, c.content, c.updated_at, c.created_at,
+			       t.depth + 1, c.position
+			FROM thread AS t
+			CROSS JOIN LATERAL (
+				SELECT r.id, r.parent_id, r.user_id, r.title, r.content, r.updated_at, r.created_at,
+				       row_number() OVER (ORDER BY r.created_at, r.id) AS position
+				FROM posts AS r
+				JOIN users AS ru ON ru.id = r.user_id
+				WHERE r.parent_id = t.id
+				  AND ru.deactivated_at IS NULL AND ru.suspended_at IS NULL
+				  AND (t.depth > 0 OR $4::timestamptz IS NULL OR (r.created_at, r.id) > ($4::timestamptz, $5::uuid))
+				ORDER BY r.created_at, r.id
+				LIMIT $3::int + 1
+			) AS c
+			-- Probe rows past the limit are returned but never expanded.
+			WHERE t.depth < $2::int AND t.position <= $3::int
+		)
+		SELECT t.id, t.parent_id, t.user_id, u.username, u.img_url, t.title, t.content,
+		       ARRAY(SELECT tg.name FROM post_tags AS pt JOIN tags AS tg ON tg.id = pt.tag_id
+		             WHERE pt.post_id = t.id ORDER BY tg.name),
+		       COALESCE((SELECT jsonb_object_agg(rc.kind, rc.count) FROM post_reaction_counts AS rc
+		                 WHERE rc.post_id = t.id AND rc.count > 0), '{}'::jsonb),
+		       COALESCE(t.updated_at, t.created_at), t.created_at, t.depth, t.position,
+		       (SELECT count(*)
+		        FROM posts AS r
+		        JOIN users AS ru ON ru.id = r.user_id
+		        WHERE r.parent_id = t.id
+		          AND ru.deactivated_at IS NULL AND ru.suspended_at IS NULL) AS reply_count
+		FROM thread AS t
+		JOIN users AS u ON u.id = t.user_id
+		ORDER BY t.depth, t.created_at, t.id
+	`
+	rows, err := r.pool.Query(ctx, query, rootID, maxDepth, limit, afterCreateAt, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread %s: %w", rootID, err)
+	}
+	defer rows.Close()
+
+	var posts []*domains.ThreadPost
+	for rows.Next() {
+		var p domains.ThreadPost
+		if err := rows.Scan(
+			&p.ID,
+			&p.ParentID,
+			&p.UserID,
+			&p.UserName,
+			&p.UserImg,
+			&p.Title,
+			&p.Content,
+			&p.Tags,
+			&p.Reactions,
+			&p.UpdateAt,
+			&p.CreateAt,
+			&p.Depth,
+			&p.Position,
+			&p.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		posts = append(posts, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, ErrPostNotFound
+	}
+	return posts, nil
+}
+
+// ts_rank_cd weights for D, C, B and A labels. Titles are labelled A and
+// content B, so a title match counts two and a half times as much.
+const searchWeights = "{0.1, 0.2, 0.4, 1.0}"
+
+var (
+	titleHeadlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`,
+		domains.HighlightStart, domains.HighlightStop)
+	contentHeadlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`,
+		domains.HighlightStart, domains.HighlightStop)
+)
+
+// SearchPosts ranks posts matching search.Query, best first. Headlines are
+// only computed for the page being returned since ts_headline has to
+// re-parse the whole document.
+func (r *postRepository) SearchPosts(ctx context.Context, search domains.PostSearch) ([]*domains.PostSearchResult, error) {
+	where, params := postFilter(search.UserID, nil)
+
+	if search.From != nil {
+		params = append(params, *search.From)
+		where += fmt.Sprintf(" AND p.created_at >= $%d", len(params))
+	}
+	if search.To != nil {
+		params = append(params, *search.To)
+		where += fmt.Sprintf(" AND p.created_at < $%d", len(params))
+	}
+
+	index := len(params) + 1
+	params = append(params, search.Query, search.Limit, search.Offset, titleHeadlineOptions, contentHeadlineOptions)
+
+	query := fmt.Sprintf(`
+		WITH q AS (SELECT websearch_to_tsquery('english', $%[3]d) AS query),
+		matches AS (
+			SELECT p.id, ts_rank_cd('%[2]s', p.search_vector, q.query) AS rank
+			FROM posts AS p
+			JOIN users AS u ON u.id = p.user_id
+			CROSS JOIN q
+			%[1]s AND p.search_vector @@ q.query
+			ORDER BY rank DESC, p.created_at DESC, p.id DESC
+			LIMIT $%[4]d OFFSET $%[5]d
+		)
+		SELECT %[8]s, m.rank,
+		       ts_headline('english', p.title, q.query, $%[6]d),
+		       ts_headline('english', p.content, q.query, $%[7]d)
+		FROM matches AS m
+		JOIN posts AS p ON p.id = m.id
+		JOIN users AS u ON u.id = p.user_id
+		CROSS JOIN q
+		ORDER BY m.rank DESC, p.created_at DESC, p.id DESC
+	`, where, searchWeights, index, index+1, index+2, index+3, index+4, postListColumns)
+
+	rows, err := r.pool.Query(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domains.PostSearchResult
+	for rows.Next() {
+		var p domains.PostSearchResult
+		if err := rows.Scan(
+			&p.ID,
+			&p.ParentID,
+			&p.UserID,
+			&p.UserName,
+			&p.UserImg,
+			&p.Title,
+			&p.Content,
+			&p.Tags,
+			&p.Reactions,
+			&p.UpdateAt,
+			&p.CreateAt,
+			&p.Rank,
+			&p.TitleHighlight,
+			&p.ContentHighlight,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// SearchTags returns tags in use that start with prefix, most used first.
+func (r *postRepository) SearchTags(ctx context.Context, prefix string, limit int) ([]*domains.Tag, error) {
+	// Tags may contain '_', which LIKE would treat as a wildcard.
+	pattern := strings.ReplaceAll(prefix, "_", `\_`) + "%"
+
+	// tags.post_count also counts posts of hidden accounts, so the count is
+	// taken over the posts the tag page would list. The stored count is an
+	// upper bound and only narrows the candidates.
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.id, t.name, count(*) AS visible_posts, t.created_at
+		FROM tags AS t
+		JOIN post_tags AS pt ON pt.tag_id = t.id
+		JOIN posts AS p ON p.id = pt.post_id
+		JOIN users AS u ON u.id = p.user_id
+		WHERE t.name LIKE $1 AND t.post_count > 0
+		  AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		GROUP BY t.id
+		ORDER BY visible_posts DESC, t.name
+		LIMIT $2
+	`, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*domains.Tag
+	for rows.Next() {
+		var t domains.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.PostCount, &t.CreateAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddReaction records userID reacting to postID with kind. Reacting twice
+// with the same kind changes nothing. Posts that do not exist or are hidden
+// fail with ErrPostNotFound.
+func (r *postRepository) AddReaction(ctx context.Context, postID uuid.UUID, userID uuid.UUID, kind string) error {
+	query := `
+		WITH target AS (
+			SELECT p.id
+			FROM posts AS p
+			JOIN users AS u ON u.id = p.user_id
+			WHERE p.id = $1 AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		), added AS (
+			INSERT INTO post_reactions (post_id, user_id, kind)
+			SELECT id, $2, $3 FROM target
+			ON CONFLICT (post_id, user_id, kind) DO NOTHING
+		)
+		SELECT EXISTS (SELECT 1 FROM target)
+	`
+	var found bool
+	if err := r.pool.QueryRow(ctx, query, postID, userID, kind).Scan(&found); err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	if !found {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// RemoveReaction takes back a reaction. Removing one that does not exist is
+// not an error.
+func (r *postRepository) RemoveReaction(ctx context.Context, postID uuid.UUID, userID uuid.UUID, kind string) error {
+	query := `DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND kind = $3`
+	if _, err := r.pool.Exec(ctx, query, postID, userID, kind); err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+// ListReactions pages through the users who reacted to postID with kind,
+// oldest first. The cursor ID is the reacting user's ID.
+func (r *postRepository) ListReactions(ctx context.Context, postID uuid.UUID, kind string, page domains.PageRequest) ([]*domains.Reaction, bool, error) {
+	where := "WHERE pr.post_id = $1 AND pr.kind = $2 AND u.deactivated_at IS NULL AND u.suspended_at IS NULL"
+	params := []any{postID, kind}
+
+	cmp, order := ">", "ASC"
+	if page.Backward {
+		cmp, order = "<", "DESC"
+	}
+	if page.Cursor != nil {
+		params = append(params, page.Cursor.CreateAt, page.Cursor.ID)
+		where += fmt.Sprintf(" AND (pr.created_at, pr.user_id) %s ($%d, $%d)", cmp, len(params)-1, len(params))
+	}
+	params = append(params, page.Limit+1)
+
+	query := fmt.Sprintf(`
+		SELECT pr.post_id, pr.user_id, u.username, u.img_url, pr.kind, pr.created_at
+		FROM post_reactions AS pr
+		JOIN users AS u ON u.id = pr.user_id
+		%s
+		ORDER BY pr.created_at %s, pr.user_id %s
+		LIMIT $%d
+	`, where, order, order, len(params))
+
+	rows, err := r.pool.Query(ctx, query, params...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []*domains.Reaction
+	for rows.Next() {
+		var re domains.Reaction
+		if err := rows.Scan(
+			&re.PostID,
+			&re.UserID,
+			&re.UserName,
+			&re.UserImg,
+			&re.Kind,
+			&re.CreateAt,
+		); err != nil {
+			return nil, false, err
+		}
+		reactions = append(reactions, &re)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(reactions) > page.Limit
+	if hasMore {
+		reactions = reactions[:page.Limit]
+	}
+	if page.Backward {
+		for i, j := 0, len(reactions)-1; i < j; i, j = i+1, j-1 {
+			reactions[i], reactions[j] = reactions[j], reactions[i]
+		}
+	}
+	return reactions, hasMore, nil
+}
+
+// ViewerReactions returns the kinds userID reacted with, for each of postIDs
+// that has any.
+func (r *postRepository) ViewerReactions(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	reactions := make(map[uuid.UUID][]string)
+	if len(postIDs) == 0 {
+		return reactions, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT post_id, kind
+		FROM post_reactions
+		WHERE user_id = $1 AND post_id = ANY($2)
+		ORDER BY post_id, kind
+	`, userID, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load viewer reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID uuid.UUID
+			kind   string
+		)
+		if err := rows.Scan(&postID, &kind); err != nil {
+			return nil, err
+		}
+		reactions[postID] = append(reactions[postID], kind)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
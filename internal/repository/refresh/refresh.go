@@ -0,0 +1,177 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTokenNotFound = errors.New("refresh token not found")
+	ErrTokenExpired  = errors.New("refresh token expired")
+	ErrTokenRevoked  = errors.New("refresh token revoked")
+	ErrTokenReused   = errors.New("refresh token reuse detected")
+)
+
+type IRefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *domains.RefreshToken) (*domains.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, tokenHash string, next *domains.RefreshToken) (*domains.RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeFamilyByTokenHash(ctx context.Context, userID uuid.UUID, tokenHash string) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+type refreshTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(pool *pgxpool.Pool) IRefreshTokenRepository {
+	return &refreshTokenRepository{pool: pool}
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *domains.RefreshToken) (*domains.RefreshToken, error) {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, family_id, token_hash, expires_at, created_at
+	`
+	var t domains.RefreshToken
+	if err := r.pool.QueryRow(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreateAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken marks the token identified by tokenHash as used and
+// stores next in the same family. Presenting a token that was already used
+// revokes the whole family and returns ErrTokenReused.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next *domains.RefreshToken) (*domains.RefreshToken, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current domains.RefreshToken
+	if err := tx.QueryRow(ctx, `
+		SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(
+		&current.ID,
+		&current.UserID,
+		&current.FamilyID,
+		&current.ExpiresAt,
+		&current.UsedAt,
+		&current.RevokedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if current.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+
+	if current.UsedAt != nil {
+		if _, err := tx.Exec(ctx, `
+			UPDATE refresh_tokens SET revoked_at = now()
+			WHERE family_id = $1 AND revoked_at IS NULL
+		`, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke refresh token family %s: %w", current.FamilyID, err)
+		}
+		// The family belongs to a session; a stolen token ends it too.
+		if _, err := tx.Exec(ctx, `
+			UPDATE sessions SET revoked_at = now()
+			WHERE id = $1 AND revoked_at IS NULL
+		`, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session %s: %w", current.FamilyID, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+
+	var t domains.RefreshToken
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, family_id, token_hash, expires_at, created_at
+	`, current.UserID, current.FamilyID, next.TokenHash, next.ExpiresAt).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreateAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+	if _, err := r.pool.Exec(ctx, query, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family %s: %w", familyID, err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamilyByTokenHash(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id FROM refresh_tokens
+			WHERE token_hash = $1 AND user_id = $2
+		)
+	`
+	if _, err := r.pool.Exec(ctx, query, tokenHash, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	if _, err := r.pool.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens for user %s: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type revocationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRevocationRepository(pool *pgxpool.Pool) token.RevocationStore {
+	return &revocationRepository{pool: pool}
+}
+
+func (r *revocationRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	if _, err := r.pool.Exec(ctx, query, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token %s: %w", jti, err)
+	}
+	return nil
+}
+
+func (r *revocationRepository) RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_user_tokens (user_id, issued_before, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET issued_before = GREATEST(revoked_user_tokens.issued_before, EXCLUDED.issued_before),
+		    expires_at = GREATEST(revoked_user_tokens.expires_at, EXCLUDED.expires_at)
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, issuedBefore, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke tokens for user %s: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *revocationRepository) IsTokenRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+		    OR EXISTS (SELECT 1 FROM revoked_user_tokens WHERE user_id = $2 AND issued_before > $3)
+	`
+
+	var revoked bool
+	if err := r.pool.QueryRow(ctx, query, jti, userID, issuedAt).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check token %s: %w", jti, err)
+	}
+	return revoked, nil
+}
+
+func (r *revocationRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+	users, err := r.pool.Exec(ctx, `DELETE FROM revoked_user_tokens WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired user revocations: %w", err)
+	}
+	return result.RowsAffected() + users.RowsAffected(), nil
+}
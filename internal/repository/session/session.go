@@ -0,0 +1,178 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/internal/domains"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type ISessionRepository interface {
+	CreateSession(ctx context.Context, session *domains.Session) (*domains.Session, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*domains.Session, error)
+	TouchSession(ctx context.Context, sessionID uuid.UUID, interval time.Duration) (bool, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keep uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+type sessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pool *pgxpool.Pool) ISessionRepository {
+	return &sessionRepository{pool: pool}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *domains.Session) (*domains.Session, error) {
+	query := `
+		INSERT INTO sessions (user_id, user_agent, ip)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, user_agent, ip, created_at, last_seen_at
+	`
+	var s domains.Session
+	if err := r.pool.QueryRow(ctx, query, session.UserID, session.UserAgent, session.IP).Scan(
+		&s.ID,
+		&s.UserID,
+		&s.UserAgent,
+		&s.IP,
+		&s.CreateAt,
+		&s.LastSeenAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]*domains.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*domains.Session
+	for rows.Next() {
+		var s domains.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreateAt, &s.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession reports whether the session is still active and bumps its
+// last_seen_at, at most once per interval so busy clients do not turn every
+// request into a write.
+func (r *sessionRepository) TouchSession(ctx context.Context, sessionID uuid.UUID, interval time.Duration) (bool, error) {
+	var revokedAt *time.Time
+	var lastSeenAt time.Time
+	err := r.pool.QueryRow(ctx, `SELECT revoked_at, last_seen_at FROM sessions WHERE id = $1`, sessionID).Scan(&revokedAt, &lastSeenAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get session %s: %w", sessionID, err)
+	}
+	if revokedAt != nil {
+		return false, nil
+	}
+
+	if time.Since(lastSeenAt) >= interval {
+		if _, err := r.pool.Exec(ctx, `UPDATE sessions SET last_seen_at = now() WHERE id = $1`, sessionID); err != nil {
+			return false, fmt.Errorf("failed to touch session %s: %w", sessionID, err)
+		}
+	}
+	return true, nil
+}
+
+// RevokeSession ends one of the user's sessions and revokes the refresh
+// tokens issued for it.
+func (r *sessionRepository) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	return r.revoke(ctx, `
+		UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND id = $2 AND revoked_at IS NULL
+		RETURNING id
+	`, userID, sessionID)
+}
+
+func (r *sessionRepository) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keep uuid.UUID) error {
+	err := r.revoke(ctx, `
+		UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+		RETURNING id
+	`, userID, keep)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (r *sessionRepository) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	err := r.revoke(ctx, `
+		UPDATE sessions SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`, userID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+// revoke runs query, which must return the ids of the sessions it revoked,
+// and revokes their refresh token families in the same transaction.
+func (r *sessionRepository) revoke(ctx context.Context, query string, args ...any) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrSessionNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE family_id = ANY($1) AND revoked_at IS NULL
+	`, ids); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens for sessions: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bariscan97/clean-rest-architecture/internal/domains"
 	"github.com/bariscan97/clean-rest-architecture/internal/utils"
@@ -12,12 +13,23 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type IUserRepository interface {
 	CreateUser(ctx context.Context, user *domains.User) (*domains.User, error)
 	ListUsers(ctx context.Context, page int, limit int) ([]*domains.User, error)
+	ListUsersByCursor(ctx context.Context, page domains.PageRequest) ([]*domains.User, bool, error)
 	GetUserByIdentifier(ctx context.Context, identifier string) (*domains.User, error)
 	UpdateUserByID(ctx context.Context, userID uuid.UUID, fields map[string]interface{}) error
 	DeleteUserByID(ctx context.Context, id uuid.UUID) error
+	UpdateUserRoles(ctx context.Context, userID uuid.UUID, roles []string) error
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error
+	ReserveVerificationEmail(ctx context.Context, userID uuid.UUID, interval time.Duration) (bool, error)
+	DeactivateUser(ctx context.Context, userID uuid.UUID, grace time.Duration) (time.Time, error)
+	ReactivateUser(ctx context.Context, userID uuid.UUID) (bool, error)
+	PurgeDeactivatedUsers(ctx context.Context) (int64, error)
+	SuspendUser(ctx context.Context, userID uuid.UUID, reason string) error
+	UnsuspendUser(ctx context.Context, userID uuid.UUID) error
 }
 
 type userRepository struct {
@@ -34,15 +46,19 @@ func (r *userRepository) CreateUser(ctx context.Context, user *domains.User) (*d
 		VALUES ($1, $2, $3)
 		RETURNING id, username, email;
 	`
-	row := r.pool.QueryRow(ctx, query, user.Email, user.UserName, user.Password)
+	row := r.pool.QueryRow(ctx, query, user.UserName, user.Email, user.Password)
 	var u domains.User
-	err := row.Scan(&u.ID, &u.Email, &u.Password)
+	err := row.Scan(&u.ID, &u.UserName, &u.Email)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
+const userListColumns = `id, username, img_url, roles, deactivated_at, purge_after, suspended_at, suspension_reason, created_at`
+
+// ListUsers pages with LIMIT/OFFSET. It is kept for clients still sending
+// ?page=; ListUsersByCursor is stable while users sign up.
 func (r *userRepository) ListUsers(ctx context.Context, page int, limit int) ([]*domains.User, error) {
 
 	if page < 1 {
@@ -55,12 +71,57 @@ func (r *userRepository) ListUsers(ctx context.Context, page int, limit int) ([]
 	offset := (page - 1) * limit
 
 	query := `
-		SELECT id, username, img_url, created_at
+		SELECT ` + userListColumns + `
 		FROM users
-		ORDER BY created_at
+		ORDER BY created_at, id
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.pool.Query(ctx, query, limit, offset)
+	return r.queryUsers(ctx, query, limit, offset)
+}
+
+// ListUsersByCursor returns up to page.Limit users, oldest first, next to
+// page.Cursor. hasMore reports whether further users exist in the
+// direction requested.
+func (r *userRepository) ListUsersByCursor(ctx context.Context, page domains.PageRequest) ([]*domains.User, bool, error) {
+	cmp, order := ">", "ASC"
+	if page.Backward {
+		cmp, order = "<", "DESC"
+	}
+
+	where := ""
+	params := []any{page.Limit + 1}
+	if page.Cursor != nil {
+		where = fmt.Sprintf("WHERE (created_at, id) %s ($2, $3)", cmp)
+		params = append(params, page.Cursor.CreateAt, page.Cursor.ID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM users
+		%s
+		ORDER BY created_at %s, id %s
+		LIMIT $1
+	`, userListColumns, where, order, order)
+
+	users, err := r.queryUsers(ctx, query, params...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(users) > page.Limit
+	if hasMore {
+		users = users[:page.Limit]
+	}
+	if page.Backward {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	return users, hasMore, nil
+}
+
+func (r *userRepository) queryUsers(ctx context.Context, query string, params ...any) ([]*domains.User, error) {
+	rows, err := r.pool.Query(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +130,7 @@ func (r *userRepository) ListUsers(ctx context.Context, page int, limit int) ([]
 	var users []*domains.User
 	for rows.Next() {
 		var u domains.User
-		if err := rows.Scan(&u.ID, &u.UserName, &u.ImgUrl, &u.CreateAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.UserName, &u.ImgUrl, &u.Roles, &u.DeactivatedAt, &u.PurgeAfter, &u.SuspendedAt, &u.SuspensionReason, &u.CreateAt); err != nil {
 			return nil, err
 		}
 		users = append(users, &u)
@@ -82,18 +143,20 @@ func (r *userRepository) ListUsers(ctx context.Context, page int, limit int) ([]
 
 func (r *userRepository) GetUserByIdentifier(ctx context.Context, identifier string) (*domains.User, error) {
 	query := `
-		SELECT id, username, img_url, email, password
+		SELECT id, username, img_url, email, password, roles, email_verified_at,
+		       deactivated_at, purge_after, suspended_at, suspension_reason
 		FROM users
-		WHERE email = $1 or username = $1 or id = $1;
+		WHERE email = $1 or username = $1 or id::text = $1;
 	`
 	row := r.pool.QueryRow(ctx, query, identifier)
 
 	var u domains.User
 
-	err := row.Scan(&u.ID, &u.UserName, &u.ImgUrl, &u.Email, &u.Password)
+	err := row.Scan(&u.ID, &u.UserName, &u.ImgUrl, &u.Email, &u.Password, &u.Roles, &u.EmailVerifiedAt,
+		&u.DeactivatedAt, &u.PurgeAfter, &u.SuspendedAt, &u.SuspensionReason)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user not found for identifier: %s", identifier)
+			return nil, fmt.Errorf("%w for identifier: %s", ErrUserNotFound, identifier)
 		}
 		return nil, err
 	}
@@ -124,3 +187,134 @@ func (r *userRepository) DeleteUserByID(ctx context.Context, id uuid.UUID) error
 	_, err := r.pool.Exec(ctx, query, id)
 	return err
 }
+
+func (r *userRepository) UpdateUserRoles(ctx context.Context, userID uuid.UUID, roles []string) error {
+	query := `
+		UPDATE users SET roles = $1, updated_at = now()
+		WHERE id = $2;
+	`
+	result, err := r.pool.Exec(ctx, query, roles, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("no rows updated for userID: %s", userID)
+	}
+	return nil
+}
+
+// MarkEmailVerified confirms email for userID. It fails if the address was
+// changed after the verification mail went out.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	query := `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, now())
+		WHERE id = $1 AND email = $2;
+	`
+	result, err := r.pool.Exec(ctx, query, userID, email)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("no unverified user %s with that email", userID)
+	}
+	return nil
+}
+
+// ReserveVerificationEmail records that a verification mail is about to be
+// sent. It returns false when the previous one went out less than interval
+// ago or the address is already verified.
+func (r *userRepository) ReserveVerificationEmail(ctx context.Context, userID uuid.UUID, interval time.Duration) (bool, error) {
+	query := `
+		UPDATE users SET email_verification_sent_at = now()
+		WHERE id = $1
+		  AND email_verified_at IS NULL
+		  AND (email_verification_sent_at IS NULL OR email_verification_sent_at < now() - $2::interval);
+	`
+	result, err := r.pool.Exec(ctx, query, userID, interval)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// DeactivateUser schedules userID for deletion once grace has passed and
+// returns when that will happen. Deactivating twice keeps the original date.
+func (r *userRepository) DeactivateUser(ctx context.Context, userID uuid.UUID, grace time.Duration) (time.Time, error) {
+	query := `
+		UPDATE users
+		SET deactivated_at = COALESCE(deactivated_at, now()),
+		    purge_after = COALESCE(purge_after, now() + $2::interval),
+		    updated_at = now()
+		WHERE id = $1
+		RETURNING purge_after;
+	`
+	var purgeAfter time.Time
+	if err := r.pool.QueryRow(ctx, query, userID, grace).Scan(&purgeAfter); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+		}
+		return time.Time{}, err
+	}
+	return purgeAfter, nil
+}
+
+// ReactivateUser cancels a pending deletion. It returns false when the
+// grace period is already over.
+func (r *userRepository) ReactivateUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE users
+		SET deactivated_at = NULL, purge_after = NULL, updated_at = now()
+		WHERE id = $1 AND deactivated_at IS NOT NULL AND purge_after > now();
+	`
+	result, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// PurgeDeactivatedUsers deletes every account whose grace period has run
+// out, together with everything that cascades from it.
+func (r *userRepository) PurgeDeactivatedUsers(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM users
+		WHERE deactivated_at IS NOT NULL AND purge_after <= now();
+	`
+	result, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *userRepository) SuspendUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	query := `
+		UPDATE users
+		SET suspended_at = COALESCE(suspended_at, now()), suspension_reason = $2, updated_at = now()
+		WHERE id = $1;
+	`
+	result, err := r.pool.Exec(ctx, query, userID, reason)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+	}
+	return nil
+}
+
+func (r *userRepository) UnsuspendUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET suspended_at = NULL, suspension_reason = NULL, updated_at = now()
+		WHERE id = $1;
+	`
+	result, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns the address of the peer that sent r. Forwarded headers are
+// ignored because they are trivially spoofed; deployments behind a trusted
+// proxy should rewrite RemoteAddr before the router.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id UUID PRIMARY KEY,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    CONSTRAINT fk_user_mfa_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    CONSTRAINT fk_mfa_recovery_codes_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_code ON mfa_recovery_codes(user_id, code_hash);
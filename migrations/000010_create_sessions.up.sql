@@ -0,0 +1,25 @@
+-- A session is one login on one device. Its id doubles as the family_id of
+-- the refresh tokens issued for it.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- Logins made before sessions existed keep working.
+INSERT INTO sessions (id, user_id, created_at, last_seen_at)
+SELECT family_id, user_id, min(created_at), max(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > now()
+GROUP BY family_id, user_id
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_id TEXT NOT NULL,
+    actor_id UUID,
+    user_id UUID,
+    event TEXT NOT NULL,
+    method TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    CONSTRAINT fk_impersonation_audit_actor FOREIGN KEY (actor_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_impersonation_audit_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_token_id ON impersonation_audit(token_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_actor_id ON impersonation_audit(actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_user_id ON impersonation_audit(user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS request_nonces (
+    key_id TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (key_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_nonces_expires_at ON request_nonces(expires_at);
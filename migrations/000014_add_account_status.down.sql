@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_purge_after;
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP COLUMN IF EXISTS purge_after;
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,24 @@
+-- user_id is cleared rather than cascaded when an account is purged so the
+-- export worker still finds the archive and removes it from the blob store.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID,
+    status TEXT NOT NULL DEFAULT 'pending',
+    blob_key TEXT,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CONSTRAINT fk_data_exports_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports(status, created_at);
+
+-- At most one export per user may be queued or running at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_data_exports_in_progress
+    ON data_exports(user_id) WHERE status IN ('pending', 'running');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_posts_parent_id_created_at_id;
+DROP INDEX IF EXISTS idx_posts_user_id_created_at_id;
+DROP INDEX IF EXISTS idx_posts_created_at_id;
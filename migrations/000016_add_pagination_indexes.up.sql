@@ -0,0 +1,7 @@
+-- Keyset pagination walks posts by (created_at, id); these cover the plain
+-- listing and the per-author and per-parent filters.
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id_created_at_id ON posts(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_parent_id_created_at_id ON posts(parent_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at, id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_tags;
+DROP FUNCTION IF EXISTS post_tags_count();
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    post_count INTEGER NOT NULL DEFAULT 0 CHECK (post_count >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Autocomplete matches on a prefix, which the unique index cannot serve
+-- under a non-C collation.
+CREATE INDEX IF NOT EXISTS idx_tags_name_prefix ON tags(name text_pattern_ops);
+
+-- explicit is false for tags taken from #hashtags in the content.
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    explicit BOOLEAN NOT NULL DEFAULT true,
+    PRIMARY KEY (post_id, tag_id),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id)
+        REFERENCES tags(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id, post_id);
+
+-- post_count is maintained here rather than in the application so that
+-- rows removed by cascades (deleted threads, purged accounts) are counted
+-- too.
+CREATE OR REPLACE FUNCTION post_tags_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE tags SET post_count = post_count + 1 WHERE id = NEW.tag_id;
+    ELSE
+        UPDATE tags SET post_count = post_count - 1 WHERE id = OLD.tag_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_post_tags_count
+    AFTER INSERT OR DELETE ON post_tags
+    FOR EACH ROW EXECUTE FUNCTION post_tags_count();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_reactions;
+DROP FUNCTION IF EXISTS post_reactions_count();
+DROP TABLE IF EXISTS post_reaction_counts;
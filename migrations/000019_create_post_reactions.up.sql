@@ -0,0 +1,51 @@
+-- A user may react to a post with several kinds, each at most once.
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (post_id, user_id, kind),
+    CONSTRAINT fk_post_reactions_post FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_post_reactions_user FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_kind ON post_reactions(post_id, kind, created_at, user_id);
+CREATE INDEX IF NOT EXISTS idx_post_reactions_user_id ON post_reactions(user_id);
+
+-- Listings read these instead of counting post_reactions per row.
+CREATE TABLE IF NOT EXISTS post_reaction_counts (
+    post_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0 CHECK (count >= 0),
+    PRIMARY KEY (post_id, kind),
+    CONSTRAINT fk_post_reaction_counts_post FOREIGN KEY (post_id)
+        REFERENCES posts(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+
+-- Like tags.post_count, maintained by trigger so reactions removed along
+-- with a purged account are uncounted too.
+CREATE OR REPLACE FUNCTION post_reactions_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO post_reaction_counts (post_id, kind, count)
+        VALUES (NEW.post_id, NEW.kind, 1)
+        ON CONFLICT (post_id, kind) DO UPDATE SET count = post_reaction_counts.count + 1;
+    ELSE
+        UPDATE post_reaction_counts SET count = count - 1
+        WHERE post_id = OLD.post_id AND kind = OLD.kind;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_post_reactions_count
+    AFTER INSERT OR DELETE ON post_reactions
+    FOR EACH ROW EXECUTE FUNCTION post_reactions_count();
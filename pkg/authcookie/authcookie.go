@@ -0,0 +1,141 @@
+// Package authcookie carries access and refresh tokens in cookies for
+// browser clients and implements the double-submit CSRF check that goes
+// with them.
+package authcookie
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+)
+
+type Manager struct {
+	enabled     bool
+	accessName  string
+	refreshName string
+	refreshPath string
+	csrfName    string
+	csrfHeader  string
+	domain      string
+	path        string
+	secure      bool
+	sameSite    http.SameSite
+}
+
+func NewManager(cfg *config.Config) *Manager {
+	c := cfg.Auth.Cookie
+
+	return &Manager{
+		enabled:     c.Enabled,
+		accessName:  c.AccessTokenName,
+		refreshName: c.RefreshTokenName,
+		refreshPath: c.RefreshTokenPath,
+		csrfName:    c.CSRFName,
+		csrfHeader:  c.CSRFHeader,
+		domain:      c.Domain,
+		path:        c.Path,
+		secure:      c.Secure,
+		sameSite:    parseSameSite(c.SameSite),
+	}
+}
+
+// Enabled reports whether logins hand out cookies instead of returning the
+// tokens in the response body.
+func (m *Manager) Enabled() bool {
+	return m.enabled
+}
+
+// SetTokens stores the token pair in HttpOnly cookies and rotates the CSRF
+// cookie. The returned CSRF token must be echoed in the CSRF header on
+// every unsafe request authenticated by these cookies.
+func (m *Manager) SetTokens(w http.ResponseWriter, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) (string, error) {
+	csrf, err := token.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, m.cookie(m.accessName, accessToken, m.path, accessExpiresAt, true))
+	http.SetCookie(w, m.cookie(m.refreshName, refreshToken, m.refreshPath, refreshExpiresAt, true))
+	// The CSRF cookie has to be readable by scripts so they can copy it into
+	// the header.
+	http.SetCookie(w, m.cookie(m.csrfName, csrf, m.path, refreshExpiresAt, false))
+
+	return csrf, nil
+}
+
+// Clear expires every cookie set by SetTokens.
+func (m *Manager) Clear(w http.ResponseWriter) {
+	expired := time.Unix(0, 0)
+	http.SetCookie(w, m.cookie(m.accessName, "", m.path, expired, true))
+	http.SetCookie(w, m.cookie(m.refreshName, "", m.refreshPath, expired, true))
+	http.SetCookie(w, m.cookie(m.csrfName, "", m.path, expired, false))
+}
+
+func (m *Manager) AccessToken(r *http.Request) (string, bool) {
+	return m.value(r, m.accessName)
+}
+
+func (m *Manager) RefreshToken(r *http.Request) (string, bool) {
+	return m.value(r, m.refreshName)
+}
+
+// ValidCSRF reports whether r may proceed on cookie authentication: safe
+// methods always pass, anything else must send the CSRF cookie's value in
+// the CSRF header.
+func (m *Manager) ValidCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	expected, ok := m.value(r, m.csrfName)
+	if !ok {
+		return false
+	}
+	got := r.Header.Get(m.csrfHeader)
+
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(expected)) == 1
+}
+
+func (m *Manager) value(r *http.Request, name string) (string, bool) {
+	if !m.enabled {
+		return "", false
+	}
+	c, err := r.Cookie(name)
+	if err != nil || c.Value == "" {
+		return "", false
+	}
+	return c.Value, true
+}
+
+func (m *Manager) cookie(name, value, path string, expires time.Time, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   m.domain,
+		Expires:  expires,
+		Secure:   m.secure,
+		HttpOnly: httpOnly,
+		SameSite: m.sameSite,
+	}
+	if value == "" {
+		c.MaxAge = -1
+	}
+	return c
+}
+
+func parseSameSite(s string) http.SameSite {
+	switch strings.ToLower(s) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,33 @@
+// Package blobstore keeps opaque files such as data export archives behind a
+// small interface so the backing storage can be swapped per deployment.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type Store interface {
+	// Put stores everything read from r under key, replacing any previous
+	// blob. A failed Put leaves no partial blob behind.
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore builds the store selected by blob.driver: "local" (default).
+func NewStore(cfg *config.Config) (Store, error) {
+	switch cfg.Blob.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.Blob.Dir)
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", cfg.Blob.Driver)
+	}
+}
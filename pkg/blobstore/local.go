@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore keeps blobs as files below dir. Keys may contain slashes,
+// which become subdirectories.
+func NewLocalStore(dir string) (Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("blob.dir is required for the local blob store")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating blob dir %s: %w", dir, err)
+	}
+	return &localStore{dir: dir}, nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("error creating blob dir: %w", err)
+	}
+
+	// Write next to the target and rename so readers never see a
+	// half-written blob.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error creating blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing blob %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing blob %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error storing blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *localStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error opening blob %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// path maps key into the store directory and refuses keys that would
+// escape it.
+func (s *localStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || !fs.ValidPath(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+    "time"
+
     "github.com/spf13/viper"
 )
 
@@ -14,8 +16,157 @@ type Config struct {
         SSLMode  string `mapstructure:"sslmode"`
     } `mapstructure:"database"`
     App struct {
-        Port int  
+        Port      int  
+        PublicURL string `mapstructure:"public_url"`
     } `mapstructure:"app"`
+    Auth struct {
+        RequireVerifiedEmail       bool          `mapstructure:"require_verified_email"`
+        EmailVerificationTTL       time.Duration `mapstructure:"email_verification_ttl"`
+        VerificationResendInterval time.Duration `mapstructure:"verification_resend_interval"`
+        PasswordResetTTL           time.Duration `mapstructure:"password_reset_ttl"`
+        PasswordResetURL           string        `mapstructure:"password_reset_url"`
+        MFAIssuer                  string        `mapstructure:"mfa_issuer"`
+        Lockout                    struct {
+            Window              time.Duration `mapstructure:"window"`
+            BaseDelay           time.Duration `mapstructure:"base_delay"`
+            MaxDelay            time.Duration `mapstructure:"max_delay"`
+            LockoutDuration     time.Duration `mapstructure:"lockout_duration"`
+            AccountFreeAttempts int           `mapstructure:"account_free_attempts"`
+            AccountThreshold    int           `mapstructure:"account_threshold"`
+            IPFreeAttempts      int           `mapstructure:"ip_free_attempts"`
+            IPThreshold         int           `mapstructure:"ip_threshold"`
+        } `mapstructure:"lockout"`
+        Cookie struct {
+            Enabled          bool   `mapstructure:"enabled"`
+            AccessTokenName  string `mapstructure:"access_token_name"`
+            RefreshTokenName string `mapstructure:"refresh_token_name"`
+            RefreshTokenPath string `mapstructure:"refresh_token_path"`
+            CSRFName         string `mapstructure:"csrf_name"`
+            CSRFHeader       string `mapstructure:"csrf_header"`
+            Domain           string `mapstructure:"domain"`
+            Path             string `mapstructure:"path"`
+            Secure           bool   `mapstructure:"secure"`
+            SameSite         string `mapstructure:"same_site"`
+        } `mapstructure:"cookie"`
+        OIDC struct {
+            StateTTL  time.Duration           `mapstructure:"state_ttl"`
+            Providers map[string]OIDCProvider `mapstructure:"providers"`
+        } `mapstructure:"oidc"`
+        RequestSigning struct {
+            Window time.Duration `mapstructure:"window"`
+            Keys   []ServiceKey  `mapstructure:"keys"`
+        } `mapstructure:"request_signing"`
+    } `mapstructure:"auth"`
+    Password struct {
+        Algorithm string `mapstructure:"algorithm"`
+        Argon2    struct {
+            Memory      uint32 `mapstructure:"memory"`
+            Iterations  uint32 `mapstructure:"iterations"`
+            Parallelism uint8  `mapstructure:"parallelism"`
+            SaltLength  uint32 `mapstructure:"salt_length"`
+            KeyLength   uint32 `mapstructure:"key_length"`
+        } `mapstructure:"argon2"`
+        BcryptCost int `mapstructure:"bcrypt_cost"`
+        Policy     struct {
+            MinLength        int     `mapstructure:"min_length"`
+            MaxLength        int     `mapstructure:"max_length"`
+            MinEntropyBits   float64 `mapstructure:"min_entropy_bits"`
+            BreachedListFile string  `mapstructure:"breached_list_file"`
+        } `mapstructure:"policy"`
+    } `mapstructure:"password"`
+    Account struct {
+        DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period"`
+    } `mapstructure:"account"`
+    Export struct {
+        Retention  time.Duration `mapstructure:"retention"`
+        LinkTTL    time.Duration `mapstructure:"link_ttl"`
+        LinkSecret string        `mapstructure:"link_secret"`
+    } `mapstructure:"export"`
+    Blob struct {
+        Driver string `mapstructure:"driver"`
+        Dir    string `mapstructure:"dir"`
+    } `mapstructure:"blob"`
+    Mail struct {
+        Driver string `mapstructure:"driver"`
+        From   string `mapstructure:"from"`
+        Dir    string `mapstructure:"dir"`
+        SMTP   struct {
+            Host     string `mapstructure:"host"`
+            Port     int    `mapstructure:"port"`
+            Username string `mapstructure:"username"`
+            Password string `mapstructure:"password"`
+        } `mapstructure:"smtp"`
+    } `mapstructure:"mail"`
+}
+
+// OIDCProvider configures one external identity provider. AuthURL, TokenURL
+// and JWKSURL are only needed for providers without discovery.
+type OIDCProvider struct {
+    Issuer       string   `mapstructure:"issuer"`
+    ClientID     string   `mapstructure:"client_id"`
+    ClientSecret string   `mapstructure:"client_secret"`
+    RedirectURL  string   `mapstructure:"redirect_url"`
+    Scopes       []string `mapstructure:"scopes"`
+    AuthURL      string   `mapstructure:"auth_url"`
+    TokenURL     string   `mapstructure:"token_url"`
+    JWKSURL      string   `mapstructure:"jwks_url"`
+}
+
+// ServiceKey is a shared secret a service signs its requests with.
+type ServiceKey struct {
+    ID      string   `mapstructure:"id"`
+    Service string   `mapstructure:"service"`
+    Secret  string   `mapstructure:"secret"`
+    Scopes  []string `mapstructure:"scopes"`
+    Roles   []string `mapstructure:"roles"`
+}
+
+func setDefaults() {
+    viper.SetDefault("app.port", 3000)
+    viper.SetDefault("app.public_url", "http://localhost:3000")
+    viper.SetDefault("auth.require_verified_email", false)
+    viper.SetDefault("auth.email_verification_ttl", 24*time.Hour)
+    viper.SetDefault("auth.verification_resend_interval", time.Minute)
+    viper.SetDefault("auth.password_reset_ttl", time.Hour)
+    viper.SetDefault("auth.password_reset_url", "http://localhost:3000/reset-password")
+    viper.SetDefault("auth.mfa_issuer", "clean-rest-architecture")
+    viper.SetDefault("auth.lockout.window", 15*time.Minute)
+    viper.SetDefault("auth.lockout.base_delay", time.Second)
+    viper.SetDefault("auth.lockout.max_delay", time.Minute)
+    viper.SetDefault("auth.lockout.lockout_duration", 15*time.Minute)
+    viper.SetDefault("auth.lockout.account_free_attempts", 3)
+    viper.SetDefault("auth.lockout.account_threshold", 10)
+    viper.SetDefault("auth.lockout.ip_free_attempts", 20)
+    viper.SetDefault("auth.lockout.ip_threshold", 100)
+    viper.SetDefault("auth.cookie.enabled", false)
+    viper.SetDefault("auth.cookie.access_token_name", "access_token")
+    viper.SetDefault("auth.cookie.refresh_token_name", "refresh_token")
+    viper.SetDefault("auth.cookie.refresh_token_path", "/api/v1/auth")
+    viper.SetDefault("auth.cookie.csrf_name", "csrf_token")
+    viper.SetDefault("auth.cookie.csrf_header", "X-CSRF-Token")
+    viper.SetDefault("auth.cookie.path", "/")
+    viper.SetDefault("auth.cookie.secure", true)
+    viper.SetDefault("auth.cookie.same_site", "lax")
+    viper.SetDefault("auth.oidc.state_ttl", 10*time.Minute)
+    viper.SetDefault("auth.request_signing.window", 5*time.Minute)
+    viper.SetDefault("password.algorithm", "argon2id")
+    viper.SetDefault("password.argon2.memory", 64*1024)
+    viper.SetDefault("password.argon2.iterations", 3)
+    viper.SetDefault("password.argon2.parallelism", 2)
+    viper.SetDefault("password.argon2.salt_length", 16)
+    viper.SetDefault("password.argon2.key_length", 32)
+    viper.SetDefault("password.bcrypt_cost", 10)
+    viper.SetDefault("password.policy.min_length", 10)
+    viper.SetDefault("password.policy.max_length", 72)
+    viper.SetDefault("password.policy.min_entropy_bits", 50)
+    viper.SetDefault("password.policy.breached_list_file", "")
+    viper.SetDefault("account.deletion_grace_period", 30*24*time.Hour)
+    viper.SetDefault("export.retention", 7*24*time.Hour)
+    viper.SetDefault("export.link_ttl", 15*time.Minute)
+    viper.SetDefault("blob.driver", "local")
+    viper.SetDefault("blob.dir", "./tmp/blobs")
+    viper.SetDefault("mail.driver", "log")
+    viper.SetDefault("mail.from", "no-reply@localhost")
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -26,6 +177,7 @@ func LoadConfig(path string) (*Config, error) {
     viper.AddConfigPath(path)
 
     viper.AutomaticEnv()
+    setDefaults()
 
     if err := viper.ReadInConfig(); err != nil {
         return nil, err
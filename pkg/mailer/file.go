@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer writes every message as an .eml file into dir so tests and
+// local setups can pick links out of delivered mail.
+func NewFileMailer(dir string, from string) (Mailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail.dir is required for the file mailer")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating mail dir %s: %w", dir, err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(format(m.from, msg)), 0o644); err != nil {
+		return fmt.Errorf("error writing mail to %s: %w", m.dir, err)
+	}
+	return nil
+}
+
+func format(from string, msg Message) string {
+	return fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, msg.To, msg.Subject, msg.Body)
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type logMailer struct{}
+
+// NewLogMailer writes every message to the application log instead of
+// delivering it. Intended for local development.
+func NewLogMailer() Mailer {
+	return logMailer{}
+}
+
+func (logMailer) Send(ctx context.Context, msg Message) error {
+	zap.L().Info("mail sent",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
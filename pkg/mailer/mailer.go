@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer builds the mailer selected by mail.driver: "log" (default),
+// "file" or "smtp".
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "file":
+		return NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
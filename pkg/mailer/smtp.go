@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strconv"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port int, username string, password string, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		addr: host + ":" + strconv.Itoa(port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(format(m.from, msg))); err != nil {
+		return fmt.Errorf("error sending mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
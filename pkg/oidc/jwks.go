@@ -0,0 +1,175 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval stops a flood of tokens with unknown kids from turning
+// into a flood of JWKS requests.
+const minRefreshInterval = time.Minute
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches a provider's signing keys and refetches them when a token
+// names a kid it has not seen, which is how providers roll keys.
+type keySet struct {
+	uri    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, client *http.Client) *keySet {
+	return &keySet{uri: uri, client: client}
+}
+
+func (s *keySet) key(ctx context.Context, kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if k, ok := s.lookup(kid); ok {
+		return k, nil
+	}
+	if time.Since(s.fetchedAt) < minRefreshInterval && s.keys != nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := s.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup finds kid; a token without a kid is accepted only when the set
+// holds exactly one key.
+func (s *keySet) lookup(kid string) (any, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.uri, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks endpoint returned %d", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&set); err != nil {
+		return fmt.Errorf("error decoding jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.publicKey()
+		if err != nil {
+			// Skip key types we do not understand rather than failing the
+			// whole set.
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
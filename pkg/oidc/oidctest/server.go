@@ -0,0 +1,232 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests. It
+// implements discovery, an authorization endpoint that approves every
+// request immediately, a token endpoint that enforces PKCE and a JWKS
+// endpoint, which is enough to drive the full login flow end to end.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "oidctest"
+
+// User is the identity the server signs in as.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	user  User
+	codes map[string]authRequest
+}
+
+type authRequest struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	user          User
+}
+
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		key:          key,
+		user: User{
+			Subject:       "test-subject",
+			Email:         "oidc-user@example.com",
+			EmailVerified: true,
+			Name:          "OIDC User",
+		},
+		codes: make(map[string]authRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// SetUser changes the identity returned by subsequent logins.
+func (s *Server) SetUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = u
+}
+
+// ProviderConfig returns provider settings pointing at this server.
+func (s *Server) ProviderConfig(redirectURL string) config.OIDCProvider {
+	return config.OIDCProvider{
+		Issuer:       s.URL,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  redirectURL,
+	}
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize approves the request without a login page and redirects back
+// with a code, as a user who is already signed in at the provider would see.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+
+	if q.Get("response_type") != "code" || q.Get("client_id") != s.ClientID || redirectURI == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "pkce required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+
+	s.mu.Lock()
+	s.codes[code] = authRequest{
+		clientID:      q.Get("client_id"),
+		redirectURI:   redirectURI,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		user:          s.user,
+	}
+	s.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := target.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	target.RawQuery = values.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	req, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	if !ok ||
+		r.PostForm.Get("client_id") != req.clientID ||
+		r.PostForm.Get("client_secret") != s.ClientSecret ||
+		r.PostForm.Get("redirect_uri") != req.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != req.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "pkce verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            s.URL,
+		"sub":            req.user.Subject,
+		"aud":            req.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          req.nonce,
+		"email":          req.user.Email,
+		"email_verified": req.user.EmailVerified,
+		"name":           req.user.Name,
+	})
+	idToken.Header["kid"] = keyID
+
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,20 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+)
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636 §4.1).
+func NewCodeVerifier() (string, error) {
+	return token.GenerateOpaqueToken()
+}
+
+// CodeChallengeS256 derives the S256 code challenge sent with the
+// authorization request.
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,271 @@
+// Package oidc signs users in through external OpenID Connect providers
+// using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const httpTimeout = 10 * time.Second
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Claims is the subset of ID token claims used to find or create a user.
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type idTokenClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// emailVerified accepts both true and "true"; some providers send strings.
+func (c *idTokenClaims) emailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu   sync.Mutex
+	meta *metadata
+	keys *keySet
+}
+
+func NewProvider(name string, cfg config.OIDCProvider) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider %q: issuer, client_id and redirect_url are required", name)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	p := &Provider{
+		name:         name,
+		issuer:       strings.TrimSuffix(cfg.Issuer, "/"),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       scopes,
+		client:       &http.Client{Timeout: httpTimeout},
+	}
+
+	// Endpoints given explicitly skip discovery entirely.
+	if cfg.AuthURL != "" && cfg.TokenURL != "" && cfg.JWKSURL != "" {
+		p.meta = &metadata{
+			Issuer:                p.issuer,
+			AuthorizationEndpoint: cfg.AuthURL,
+			TokenEndpoint:         cfg.TokenURL,
+			JWKSURI:               cfg.JWKSURL,
+		}
+		p.keys = newKeySet(cfg.JWKSURL, p.client)
+	}
+
+	return p, nil
+}
+
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL builds the URL the browser is sent to. state and nonce are
+// checked again on the way back; codeChallenge is the S256 challenge for
+// the verifier passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades an authorization code for the provider's tokens and
+// returns the verified ID token claims.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"code_verifier": {codeVerifier},
+	}
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling token endpoint: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d: %s", res.StatusCode, body)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("error decoding token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature against the provider's JWKS along with
+// issuer, audience, expiry and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+
+	var claims idTokenClaims
+	if _, err := parser.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	return &Claims{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.emailVerified(),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// discover loads the provider metadata once and keeps it for the life of
+// the process. A failed attempt is retried on the next call.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching oidc discovery document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery returned %d", res.StatusCode)
+	}
+
+	var meta metadata
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("error decoding oidc discovery document: %w", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc discovery issuer %q does not match %q", meta.Issuer, p.issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is missing endpoints")
+	}
+
+	p.meta = &meta
+	p.keys = newKeySet(meta.JWKSURI, p.client)
+	return p.meta, nil
+}
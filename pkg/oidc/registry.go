@@ -0,0 +1,44 @@
+package oidc
+
+import (
+	"sort"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+// Registry holds the configured providers by name, the {provider} segment
+// of /api/v1/auth/oidc/{provider}.
+type Registry struct {
+	providers map[string]*Provider
+}
+
+func NewRegistry(cfg *config.Config) (*Registry, error) {
+	r := &Registry{providers: make(map[string]*Provider)}
+	for name, pc := range cfg.Auth.OIDC.Providers {
+		p, err := NewProvider(name, pc)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(p)
+	}
+	return r, nil
+}
+
+// Register adds or replaces a provider.
+func (r *Registry) Register(p *Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
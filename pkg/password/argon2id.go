@@ -0,0 +1,105 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+type argon2idAlgorithm struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+var b64 = base64.RawStdEncoding
+
+func (a *argon2idAlgorithm) owns(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// hash returns a PHC string: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<lanes>$<salt>$<key>
+func (a *argon2idAlgorithm) hash(password string) (string, error) {
+	salt := make([]byte, a.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.iterations, a.memory, a.parallelism, a.keyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.memory, a.iterations, a.parallelism, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func (a *argon2idAlgorithm) verify(password string, encoded string) (bool, error) {
+	h, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1, nil
+}
+
+func (a *argon2idAlgorithm) outdated(encoded string) bool {
+	h, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return h.memory != a.memory ||
+		h.iterations != a.iterations ||
+		h.parallelism != a.parallelism ||
+		uint32(len(h.salt)) != a.saltLength ||
+		uint32(len(h.key)) != a.keyLength
+}
+
+func parseArgon2id(encoded string) (*argon2idHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var h argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	// argon2.IDKey panics on zero iterations or lanes.
+	if h.iterations == 0 || h.parallelism == 0 {
+		return nil, fmt.Errorf("invalid argon2id parameters: t and p must be positive")
+	}
+
+	var err error
+	if h.salt, err = b64.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if h.key, err = b64.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	if len(h.key) == 0 {
+		return nil, fmt.Errorf("invalid argon2id key: empty")
+	}
+
+	return &h, nil
+}
@@ -0,0 +1,41 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptAlgorithm struct {
+	cost int
+}
+
+// owns matches the modular crypt prefixes bcrypt emits; they already record
+// the cost, so no extra encoding is needed.
+func (a *bcryptAlgorithm) owns(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (a *bcryptAlgorithm) hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (a *bcryptAlgorithm) verify(password string, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (a *bcryptAlgorithm) outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != a.cost
+}
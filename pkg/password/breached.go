@@ -0,0 +1,66 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const breachedPrefixLength = 5
+
+// BreachedList is an offline set of compromised password hashes. The file
+// holds one upper-case SHA-1 hex digest per line, optionally followed by
+// ":<count>" as in the Pwned Passwords downloads; blank lines and lines
+// starting with '#' are skipped. Digests are bucketed by their five
+// character prefix so a lookup only scans a handful of suffixes.
+type BreachedList struct {
+	buckets map[string][]string
+}
+
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedList{buckets: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if i := strings.IndexByte(entry, ':'); i >= 0 {
+			entry = entry[:i]
+		}
+		entry = strings.ToUpper(entry)
+		if _, err := hex.DecodeString(entry); err != nil || len(entry) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password list line %d: expected a SHA-1 hex digest", line)
+		}
+
+		prefix := entry[:breachedPrefixLength]
+		list.buckets[prefix] = append(list.buckets[prefix], entry[breachedPrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading breached password list: %w", err)
+	}
+
+	return list, nil
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	for _, suffix := range l.buckets[digest[:breachedPrefixLength]] {
+		if suffix == digest[breachedPrefixLength:] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBreachedList(t *testing.T) {
+	list, err := LoadBreachedList(filepath.Join("testdata", "breached.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range []string{"password", "123456", "letmein"} {
+		if !list.Contains(password) {
+			t.Errorf("Contains(%q) = false, want true", password)
+		}
+	}
+	for _, password := range []string{"Password", "correct-Horse7", ""} {
+		if list.Contains(password) {
+			t.Errorf("Contains(%q) = true, want false", password)
+		}
+	}
+}
+
+func TestLoadBreachedListRejectsMalformedLines(t *testing.T) {
+	tests := map[string]string{
+		"short digest":   "5BAA61E4C9B93F3F:12\n",
+		"not hex":        "ZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3\n",
+		"plain password": "# header\nhunter2\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "breached.txt")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadBreachedList(path); err == nil {
+				t.Error("LoadBreachedList accepted a malformed line")
+			}
+		})
+	}
+}
+
+func TestLoadBreachedListMissingFile(t *testing.T) {
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadBreachedList succeeded for a missing file")
+	}
+}
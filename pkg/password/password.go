@@ -0,0 +1,97 @@
+// Package password hashes and verifies user passwords. New hashes use the
+// configured algorithm; hashes of every supported algorithm keep verifying
+// so existing accounts survive a switch.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(password string, encoded string) (bool, error)
+	// outdated reports whether encoded was produced with other parameters
+	// than the ones this algorithm is configured with.
+	outdated(encoded string) bool
+	owns(encoded string) bool
+}
+
+type Hasher struct {
+	preferred  algorithm
+	algorithms []algorithm
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+func NewHasher(cfg *config.Config) (*Hasher, error) {
+	p := cfg.Password
+
+	argon := &argon2idAlgorithm{
+		memory:      p.Argon2.Memory,
+		iterations:  p.Argon2.Iterations,
+		parallelism: p.Argon2.Parallelism,
+		saltLength:  p.Argon2.SaltLength,
+		keyLength:   p.Argon2.KeyLength,
+	}
+	bc := &bcryptAlgorithm{cost: p.BcryptCost}
+
+	h := &Hasher{algorithms: []algorithm{argon, bc}}
+	switch strings.ToLower(p.Algorithm) {
+	case "", AlgorithmArgon2id:
+		h.preferred = argon
+	case AlgorithmBcrypt:
+		h.preferred = bc
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %q", p.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	encoded, err := h.preferred.hash(password)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return encoded, nil
+}
+
+// Verify checks password against encoded. needsRehash is true when the
+// password matched but encoded does not use the current algorithm and
+// parameters, so the caller should store a fresh Hash.
+func (h *Hasher) Verify(password string, encoded string) (ok bool, needsRehash bool, err error) {
+	for _, a := range h.algorithms {
+		if !a.owns(encoded) {
+			continue
+		}
+		ok, err := a.verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, a != h.preferred || a.outdated(encoded), nil
+	}
+	return false, false, ErrUnknownHash
+}
+
+// VerifyDummy does the work of a real Verify against a throwaway hash. Call
+// it when the account does not exist so response times do not reveal which
+// identifiers are registered.
+func (h *Hasher) VerifyDummy(password string) {
+	h.dummyOnce.Do(func() {
+		h.dummy, _ = h.preferred.hash("dummy-password")
+	})
+	h.preferred.verify(password, h.dummy)
+}
@@ -0,0 +1,171 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+// Parameters are kept tiny so the tests stay fast.
+func testConfig(algorithm string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Password.Algorithm = algorithm
+	cfg.Password.Argon2.Memory = 64
+	cfg.Password.Argon2.Iterations = 1
+	cfg.Password.Argon2.Parallelism = 1
+	cfg.Password.Argon2.SaltLength = 16
+	cfg.Password.Argon2.KeyLength = 32
+	cfg.Password.BcryptCost = 4
+	return cfg
+}
+
+func newTestHasher(t *testing.T, cfg *config.Config) *Hasher {
+	t.Helper()
+	h, err := NewHasher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	h := newTestHasher(t, testConfig(AlgorithmArgon2id))
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("Hash = %q, want a PHC string with the configured parameters", encoded)
+	}
+
+	again, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == encoded {
+		t.Error("two hashes of the same password are identical; the salt is not random")
+	}
+
+	ok, needsRehash, err := h.Verify("correct horse", encoded)
+	if err != nil || !ok || needsRehash {
+		t.Errorf("Verify(correct) = %v, %v, %v; want true, false, nil", ok, needsRehash, err)
+	}
+	ok, _, err = h.Verify("wrong horse", encoded)
+	if err != nil || ok {
+		t.Errorf("Verify(wrong) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestArgon2idOutdated(t *testing.T) {
+	encoded, err := newTestHasher(t, testConfig(AlgorithmArgon2id)).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*config.Config)
+	}{
+		{"memory", func(c *config.Config) { c.Password.Argon2.Memory = 128 }},
+		{"iterations", func(c *config.Config) { c.Password.Argon2.Iterations = 2 }},
+		{"parallelism", func(c *config.Config) { c.Password.Argon2.Parallelism = 2 }},
+		{"salt length", func(c *config.Config) { c.Password.Argon2.SaltLength = 24 }},
+		{"key length", func(c *config.Config) { c.Password.Argon2.KeyLength = 16 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(AlgorithmArgon2id)
+			tt.change(cfg)
+
+			ok, needsRehash, err := newTestHasher(t, cfg).Verify("correct horse", encoded)
+			if err != nil || !ok {
+				t.Fatalf("Verify = %v, %v; old parameters must keep verifying", ok, err)
+			}
+			if !needsRehash {
+				t.Error("needsRehash = false after changing the parameters")
+			}
+		})
+	}
+}
+
+func TestBcryptIsRehashedToArgon2id(t *testing.T) {
+	legacy, err := newTestHasher(t, testConfig(AlgorithmBcrypt)).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(legacy, "$2a$04$") {
+		t.Fatalf("Hash = %q, want a bcrypt hash with cost 4", legacy)
+	}
+
+	ok, needsRehash, err := newTestHasher(t, testConfig(AlgorithmArgon2id)).Verify("correct horse", legacy)
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("Verify(bcrypt) = %v, %v, %v; want true, true, nil", ok, needsRehash, err)
+	}
+
+	// A wrong password never asks for a rehash.
+	ok, needsRehash, err = newTestHasher(t, testConfig(AlgorithmArgon2id)).Verify("wrong horse", legacy)
+	if err != nil || ok || needsRehash {
+		t.Errorf("Verify(wrong) = %v, %v, %v; want false, false, nil", ok, needsRehash, err)
+	}
+}
+
+func TestBcryptCostChange(t *testing.T) {
+	legacy, err := newTestHasher(t, testConfig(AlgorithmBcrypt)).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig(AlgorithmBcrypt)
+	cfg.Password.BcryptCost = 5
+	ok, needsRehash, err := newTestHasher(t, cfg).Verify("correct horse", legacy)
+	if err != nil || !ok || !needsRehash {
+		t.Errorf("Verify = %v, %v, %v; want true, true, nil", ok, needsRehash, err)
+	}
+}
+
+func TestVerifyMalformedHashes(t *testing.T) {
+	h := newTestHasher(t, testConfig(AlgorithmArgon2id))
+	valid, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, "$")
+	salt, key := parts[4], parts[5]
+
+	tests := map[string]string{
+		"unknown algorithm": "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5",
+		"plain text":        "correct horse",
+		"missing key":       "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+		"extra field":       valid + "$extra",
+		"argon2i":           "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key,
+		"bad version":       "$argon2id$v=x$m=64,t=1,p=1$" + salt + "$" + key,
+		"old version":       "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key,
+		"bad parameters":    "$argon2id$v=19$m=64;t=1;p=1$" + salt + "$" + key,
+		"zero iterations":   "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"zero lanes":        "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"bad salt":          "$argon2id$v=19$m=64,t=1,p=1$not*base64$" + key,
+		"bad key":           "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$not*base64",
+		"empty key":         "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"truncated bcrypt":  "$2a$04$short",
+		"bcrypt wrong cost": "$2a$99$" + strings.Repeat("a", 53),
+	}
+	for name, encoded := range tests {
+		t.Run(name, func(t *testing.T) {
+			ok, needsRehash, err := h.Verify("correct horse", encoded)
+			if ok || needsRehash {
+				t.Errorf("Verify(%q) = %v, %v; want false, false", encoded, ok, needsRehash)
+			}
+			if err == nil {
+				t.Errorf("Verify(%q) returned no error", encoded)
+			}
+		})
+	}
+}
+
+func TestNewHasherRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewHasher(testConfig("md5")); err == nil {
+		t.Error("NewHasher accepted an unknown algorithm")
+	}
+}
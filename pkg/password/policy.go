@@ -0,0 +1,142 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+const (
+	ViolationTooShort = "too_short"
+	ViolationTooLong  = "too_long"
+	ViolationWeak     = "too_weak"
+	ViolationBreached = "breached"
+)
+
+// Violation is one reason a password was rejected.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+type Policy struct {
+	minLength      int
+	maxLength      int
+	minEntropyBits float64
+	breached       *BreachedList
+}
+
+func NewPolicy(cfg *config.Config) (*Policy, error) {
+	p := cfg.Password.Policy
+
+	policy := &Policy{
+		minLength:      p.MinLength,
+		maxLength:      p.MaxLength,
+		minEntropyBits: p.MinEntropyBits,
+	}
+
+	if p.BreachedListFile != "" {
+		list, err := LoadBreachedList(p.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = list
+	}
+
+	return policy, nil
+}
+
+// Check returns every rule password breaks, or nil when it is acceptable.
+func (p *Policy) Check(password string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters", p.minLength),
+		})
+	}
+	if p.maxLength > 0 && len(password) > p.maxLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooLong,
+			Message: fmt.Sprintf("password must be at most %d bytes", p.maxLength),
+		})
+	}
+	if Entropy(password) < p.minEntropyBits {
+		violations = append(violations, Violation{
+			Code:    ViolationWeak,
+			Message: "password is too easy to guess; use a longer or more varied password",
+		})
+	}
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, Violation{
+			Code:    ViolationBreached,
+			Message: "password has appeared in a data breach; choose a different one",
+		})
+	}
+
+	return violations
+}
+
+// Entropy estimates the strength of password in bits as
+// effectiveLength * log2(poolSize). The pool grows with each character class
+// in use, and characters that repeat the previous one or continue a run
+// ("aaa", "abc", "321") add nothing to the length.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	var prev, prevDelta rune
+	effective := 0
+
+	for i, r := range password {
+		switch {
+		case r <= unicode.MaxASCII && unicode.IsLower(r):
+			lower = true
+		case r <= unicode.MaxASCII && unicode.IsUpper(r):
+			upper = true
+		case r <= unicode.MaxASCII && unicode.IsDigit(r):
+			digit = true
+		case r <= unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		if i > 0 {
+			d := r - prev
+			run := d == 0 || ((d == 1 || d == -1) && d == prevDelta)
+			prevDelta = d
+			prev = r
+			if run {
+				continue
+			}
+		}
+		prev = r
+		effective++
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effective) * math.Log2(float64(pool))
+}
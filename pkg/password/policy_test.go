@@ -0,0 +1,83 @@
+package password
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		// effective characters and pool size the estimate should use
+		effective int
+		pool      int
+	}{
+		{"", 0, 0},
+		{"aaaa", 1, 26},
+		{"abcd", 2, 26},
+		{"dcba", 2, 26},
+		{"4321", 2, 10},
+		{"1357", 4, 10},
+		{"abab", 4, 26},
+		{"aB3$", 4, 95},
+		{"Password1", 8, 62}, // "ss" counts once
+		{"üü", 1, 100},
+		{"aaaaaaaabbbbbbbb", 2, 26},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			want := 0.0
+			if tt.pool > 0 {
+				want = float64(tt.effective) * math.Log2(float64(tt.pool))
+			}
+			if got := Entropy(tt.password); math.Abs(got-want) > 1e-9 {
+				t.Errorf("Entropy(%q) = %.2f, want %.2f", tt.password, got, want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{minLength: 8, maxLength: 16, minEntropyBits: 30}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"acceptable", "correct-Horse7", nil},
+		{"too short", "aB3$xY", []string{ViolationTooShort}},
+		{"repetitive", "aaaaaaaaaaaa", []string{ViolationWeak}},
+		{"sequential", "abcdefghijkl", []string{ViolationWeak}},
+		{"too long", "correct-Horse7-battery", []string{ViolationTooLong}},
+		// The upper limit counts bytes: 9 characters, 18 bytes.
+		{"too long in bytes", "éàüöçñøåß", []string{ViolationTooLong}},
+		{"several", "aaa", []string{ViolationTooShort, ViolationWeak}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range policy.Check(tt.password) {
+				got = append(got, v.Code)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckBreached(t *testing.T) {
+	list, err := LoadBreachedList(filepath.Join("testdata", "breached.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{minLength: 1, breached: list}
+
+	violations := policy.Check("letmein")
+	if len(violations) != 1 || violations[0].Code != ViolationBreached {
+		t.Errorf("Check(letmein) = %v, want a single %s violation", violations, ViolationBreached)
+	}
+}
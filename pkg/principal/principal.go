@@ -0,0 +1,72 @@
+// Package principal carries the authenticated caller through a request
+// context. It is the only place that knows the context key, so handlers and
+// middleware can never disagree about it.
+package principal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/token"
+)
+
+// Kind tells apart the ways a caller can authenticate.
+type Kind string
+
+const (
+	KindUser     Kind = "user"
+	KindService  Kind = "service"
+	KindAPIToken Kind = "api_token"
+)
+
+// ErrMissing is the panic value of MustFrom. Recover middleware answers it
+// with 401 instead of 500.
+var ErrMissing = errors.New("no authenticated principal in context")
+
+// Principal is the authenticated caller. It embeds the claims so handlers
+// can keep reading ID, Roles, SessionID and friends directly.
+type Principal struct {
+	Kind Kind
+	*token.UserClaims
+}
+
+type contextKey struct{}
+
+// IsUser reports whether the principal acts for a user account, either
+// through an interactive login or one of the user's API tokens.
+func (p *Principal) IsUser() bool {
+	return p.Kind == KindUser || p.Kind == KindAPIToken
+}
+
+// New wraps claims and derives the principal's kind from them.
+func New(claims *token.UserClaims) *Principal {
+	kind := KindUser
+	switch {
+	case claims.IsService():
+		kind = KindService
+	case claims.PersonalAccessTokenID != nil:
+		kind = KindAPIToken
+	}
+	return &Principal{Kind: kind, UserClaims: claims}
+}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFrom returns the principal attached to ctx. ok is false for
+// anonymous requests.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil && p.UserClaims != nil
+}
+
+// MustFrom is PrincipalFrom for code that only runs behind authentication.
+// It panics with ErrMissing when that assumption is broken.
+func MustFrom(ctx context.Context) *Principal {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		panic(ErrMissing)
+	}
+	return p
+}
@@ -0,0 +1,132 @@
+package reqsign
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// NonceStore remembers nonces until they fall out of the timestamp window.
+// Remember returns false when the nonce was already seen for keyID.
+type NonceStore interface {
+	Remember(ctx context.Context, keyID string, nonce string, expiresAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Key is a shared secret issued to one service.
+type Key struct {
+	ID      string
+	Service string
+	Secret  []byte
+	Scopes  []string
+	Roles   []string
+}
+
+// PrincipalID is the stable UUID the service acts under. It is derived from
+// the service name, so all keys of a service share it and rotating a key
+// does not change it.
+func (k *Key) PrincipalID() uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("service:"+k.Service))
+}
+
+type Verifier struct {
+	keys   map[string]*Key
+	window time.Duration
+	nonces NonceStore
+	now    func() time.Time
+}
+
+func NewVerifier(cfg *config.Config, nonces NonceStore) (*Verifier, error) {
+	rs := cfg.Auth.RequestSigning
+
+	v := &Verifier{
+		keys:   make(map[string]*Key, len(rs.Keys)),
+		window: rs.Window,
+		nonces: nonces,
+		now:    time.Now,
+	}
+	for _, k := range rs.Keys {
+		if k.ID == "" || k.Service == "" || k.Secret == "" {
+			return nil, fmt.Errorf("request signing key %q: id, service and secret are required", k.ID)
+		}
+		if _, dup := v.keys[k.ID]; dup {
+			return nil, fmt.Errorf("request signing key %q is configured twice", k.ID)
+		}
+
+		scopes := k.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		v.keys[k.ID] = &Key{ID: k.ID, Service: k.Service, Secret: []byte(k.Secret), Scopes: scopes, Roles: k.Roles}
+	}
+
+	return v, nil
+}
+
+// Verify checks r's signature, timestamp and nonce and returns the key it
+// was signed with. r.Body is left readable for the handler.
+func (v *Verifier) Verify(r *http.Request) (*Key, error) {
+	h, err := parseHeader(r.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := v.keys[h.keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	now := v.now()
+	if h.timestamp.Before(now.Add(-v.window)) || h.timestamp.After(now.Add(v.window)) {
+		return nil, ErrExpired
+	}
+
+	bodyHash, err := hashBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := sign(key.Secret, stringToSign(r, strconv.FormatInt(h.timestamp.Unix(), 10), h.nonce, bodyHash))
+	want, _ := hex.DecodeString(expected)
+	if !hmac.Equal(want, h.signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	// Only remember nonces of correctly signed requests so nobody can burn
+	// a service's nonces without its key. A nonce stays reserved until its
+	// timestamp could no longer pass the window check.
+	fresh, err := v.nonces.Remember(r.Context(), key.ID, h.nonce, h.timestamp.Add(v.window))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrReplayed
+	}
+
+	return key, nil
+}
+
+// StartSweeper drops expired nonces every interval until ctx is cancelled.
+func (v *Verifier) StartSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := v.nonces.DeleteExpired(ctx); err != nil {
+				zap.L().Error("error sweeping request nonces", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package reqsign
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bariscan97/clean-rest-architecture/pkg/config"
+)
+
+const (
+	testKeyID  = "billing-1"
+	testSecret = "0123456789abcdef0123456789abcdef"
+)
+
+type memoryNonces map[string]time.Time
+
+func (m memoryNonces) Remember(ctx context.Context, keyID string, nonce string, expiresAt time.Time) (bool, error) {
+	if _, seen := m[keyID+"/"+nonce]; seen {
+		return false, nil
+	}
+	m[keyID+"/"+nonce] = expiresAt
+	return true, nil
+}
+
+func (m memoryNonces) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+var testNow = time.Unix(1700000000, 0)
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Auth.RequestSigning.Window = 5 * time.Minute
+	cfg.Auth.RequestSigning.Keys = []config.ServiceKey{
+		{ID: testKeyID, Service: "billing", Secret: testSecret, Scopes: []string{"posts:read"}},
+	}
+
+	v, err := NewVerifier(cfg, memoryNonces{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func signedRequest(t *testing.T, body string, keyID string, at time.Time) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/posts?user_id=42&x=a%20b", strings.NewReader(body))
+	if err := Sign(r, keyID, []byte(testSecret), at); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestVerifyValidSignature(t *testing.T) {
+	v := newTestVerifier(t)
+	r := signedRequest(t, `{"title":"hi"}`, testKeyID, testNow)
+
+	key, err := v.Verify(r)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if key.ID != testKeyID || key.Service != "billing" {
+		t.Errorf("Verify returned key %q of %q", key.ID, key.Service)
+	}
+
+	// The handler still gets the whole body.
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != `{"title":"hi"}` {
+		t.Errorf("body after Verify = %q", body)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		build  func(t *testing.T) *http.Request
+		expect error
+	}{
+		{"tampered body", func(t *testing.T) *http.Request {
+			r := signedRequest(t, `{"title":"hi"}`, testKeyID, testNow)
+			r.Body = io.NopCloser(strings.NewReader(`{"title":"bye"}`))
+			return r
+		}, ErrInvalidSignature},
+		{"tampered query", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "", testKeyID, testNow)
+			r.URL.RawQuery = "user_id=43"
+			return r
+		}, ErrInvalidSignature},
+		{"tampered method", func(t *testing.T) *http.Request {
+			r := signedRequest(t, "", testKeyID, testNow)
+			r.Method = http.MethodDelete
+			return r
+		}, ErrInvalidSignature},
+		{"stale timestamp", func(t *testing.T) *http.Request {
+			return signedRequest(t, "", testKeyID, testNow.Add(-6*time.Minute))
+		}, ErrExpired},
+		{"future timestamp", func(t *testing.T) *http.Request {
+			return signedRequest(t, "", testKeyID, testNow.Add(6*time.Minute))
+		}, ErrExpired},
+		{"unknown key id", func(t *testing.T) *http.Request {
+			return signedRequest(t, "", "billing-2", testNow)
+		}, ErrUnknownKey},
+		{"missing header", func(t *testing.T) *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/", nil)
+		}, ErrMalformed},
+		{"malformed signature", func(t *testing.T) *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "HMAC-SHA256 KeyId=billing-1, Timestamp=1700000000, Nonce=n, Signature=zz")
+			return r
+		}, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTestVerifier(t).Verify(tt.build(t)); !errors.Is(err, tt.expect) {
+				t.Errorf("Verify error = %v, want %v", err, tt.expect)
+			}
+		})
+	}
+}
+
+func TestVerifyWindowEdges(t *testing.T) {
+	for _, offset := range []time.Duration{-5 * time.Minute, 5 * time.Minute} {
+		if _, err := newTestVerifier(t).Verify(signedRequest(t, "", testKeyID, testNow.Add(offset))); err != nil {
+			t.Errorf("Verify at %v: %v", offset, err)
+		}
+	}
+}
+
+func TestVerifyRejectsReplayedNonce(t *testing.T) {
+	v := newTestVerifier(t)
+	r := signedRequest(t, `{"title":"hi"}`, testKeyID, testNow)
+	header := r.Header.Get("Authorization")
+
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("first Verify: %v", err)
+	}
+
+	replay := httptest.NewRequest(http.MethodPost, "/api/v1/posts?user_id=42&x=a%20b", strings.NewReader(`{"title":"hi"}`))
+	replay.Header.Set("Authorization", header)
+	if _, err := v.Verify(replay); !errors.Is(err, ErrReplayed) {
+		t.Errorf("replayed Verify error = %v, want %v", err, ErrReplayed)
+	}
+}
+
+func TestVerifyDoesNotBurnNonceOfForgedRequest(t *testing.T) {
+	v := newTestVerifier(t)
+	r := signedRequest(t, `{"title":"hi"}`, testKeyID, testNow)
+	header := r.Header.Get("Authorization")
+
+	forged := httptest.NewRequest(http.MethodPost, "/api/v1/posts?user_id=42&x=a%20b", strings.NewReader(`{"title":"bye"}`))
+	forged.Header.Set("Authorization", header)
+	if _, err := v.Verify(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("forged Verify error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	if _, err := v.Verify(r); err != nil {
+		t.Errorf("genuine Verify after forgery: %v", err)
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/a%2Fb?z=1&a=2", nil)
+	got := stringToSign(r, "1700000000", "nonce", "bodyhash")
+	want := "HMAC-SHA256\n1700000000\nnonce\nPUT\n/api/v1/a%2Fb\nz=1&a=2\nbodyhash"
+	if got != want {
+		t.Errorf("stringToSign = %q, want %q", got, want)
+	}
+}
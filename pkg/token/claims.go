@@ -8,20 +8,97 @@ import (
 	"github.com/google/uuid"
 )
 
+// PurposeEmailVerification marks tokens that are mailed out to confirm an
+// address. Tokens with a purpose are never accepted as access tokens.
+const PurposeEmailVerification = "email_verification"
+
+// PurposeMFAPending marks the short-lived challenge handed out after a
+// correct password when the account still has to pass TOTP.
+const PurposeMFAPending = "mfa_pending"
+
+func init() {
+	// iat carries milliseconds so RevokeUser can tell a token issued just
+	// before a revocation from one issued after it within the same second.
+	jwt.TimePrecision = time.Millisecond
+}
+
 type UserClaims struct {
-	ID       uuid.UUID `json:"id"`
-	Email    string    `json:"email"`
-	UserName string    `json:"username"`
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	UserName      string    `json:"username"`
+	EmailVerified bool      `json:"email_verified,omitempty"`
+	Roles         []string  `json:"roles,omitempty"`
+	Scopes        []string  `json:"scopes,omitempty"`
+	Purpose       string    `json:"purpose,omitempty"`
+	// SessionID ties an access token to the login session it came from so
+	// revoking the session also rejects the token.
+	SessionID *uuid.UUID `json:"sid,omitempty"`
+	// Actor is set on impersonation tokens and names the admin acting as
+	// the user (RFC 8693 "act" claim).
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
+
+	// PersonalAccessTokenID is set when the request was authenticated with a
+	// personal access token instead of a JWT.
+	PersonalAccessTokenID *uuid.UUID `json:"-"`
+
+	// Service names the calling service when the request was authenticated
+	// with an HMAC request signature rather than a user credential.
+	Service string `json:"-"`
+}
+
+type Actor struct {
+	Subject  string    `json:"sub"`
+	ID       uuid.UUID `json:"id"`
+	UserName string    `json:"username,omitempty"`
+}
+
+// ClaimsOption sets optional claims on a token being issued.
+type ClaimsOption func(*UserClaims)
+
+func WithScopes(scopes []string) ClaimsOption {
+	return func(c *UserClaims) {
+		c.Scopes = scopes
+	}
+}
+
+func WithEmailVerified(verified bool) ClaimsOption {
+	return func(c *UserClaims) {
+		c.EmailVerified = verified
+	}
 }
 
-func NewUserClaims(id uuid.UUID, username string, email string, duration time.Duration) (*UserClaims, error) {
+func WithPurpose(purpose string) ClaimsOption {
+	return func(c *UserClaims) {
+		c.Purpose = purpose
+	}
+}
+
+func WithSessionID(id uuid.UUID) ClaimsOption {
+	return func(c *UserClaims) {
+		c.SessionID = &id
+	}
+}
+
+func WithActor(actor Actor) ClaimsOption {
+	return func(c *UserClaims) {
+		c.Actor = &actor
+	}
+}
+
+func WithRoles(roles []string) ClaimsOption {
+	return func(c *UserClaims) {
+		c.Roles = roles
+	}
+}
+
+func NewUserClaims(id uuid.UUID, username string, email string, duration time.Duration, opts ...ClaimsOption) (*UserClaims, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, fmt.Errorf("error generating token ID: %w", err)
 	}
 
-	return &UserClaims{
+	claims := &UserClaims{
 		UserName: username,
 		Email:    email,
 		ID:       id,
@@ -31,5 +108,46 @@ func NewUserClaims(id uuid.UUID, username string, email string, duration time.Du
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 		},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+
+	return claims, nil
+}
+
+// IsService reports whether the caller is a service, not a user.
+func (c *UserClaims) IsService() bool {
+	return c.Service != ""
+}
+
+// IsImpersonated reports whether the token was issued to an admin acting as
+// the user.
+func (c *UserClaims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+func (c *UserClaims) HasRole(roles ...string) bool {
+	for _, have := range c.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the token may be used for scope. Tokens from an
+// interactive login carry no scopes and are not restricted.
+func (c *UserClaims) HasScope(scope string) bool {
+	if c.PersonalAccessTokenID == nil && c.Scopes == nil {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
@@ -8,24 +8,31 @@ import (
 	"github.com/google/uuid"
 )
 
-type AuthKey struct{}
-
 type JWTMaker struct {
-	secretKey string
+	keyring *Keyring
 }
 
+// NewJWTMaker returns an HS256 maker for a single shared secret.
 func NewJWTMaker(secretKey string) *JWTMaker {
-	return &JWTMaker{secretKey}
+	keyring, _ := NewKeyring(NewHMACSigner([]byte(secretKey)))
+	return &JWTMaker{keyring}
+}
+
+func NewJWTMakerWithKeyring(keyring *Keyring) *JWTMaker {
+	return &JWTMaker{keyring}
 }
 
-func (maker *JWTMaker) CreateToken(id uuid.UUID, username string, email string, duration time.Duration) (string, *UserClaims, error) {
-	claims, err := NewUserClaims(id, email, username,duration)
+func (maker *JWTMaker) CreateToken(id uuid.UUID, username string, email string, duration time.Duration, opts ...ClaimsOption) (string, *UserClaims, error) {
+	claims, err := NewUserClaims(id, username, email, duration, opts...)
 	if err != nil {
 		return "", nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(maker.secretKey))
+	signer := maker.keyring.Current()
+	token := jwt.NewWithClaims(signer.Method(), claims)
+	token.Header["kid"] = signer.KeyID()
+
+	tokenStr, err := token.SignedString(signer.SigningKey())
 	if err != nil {
 		return "", nil, fmt.Errorf("error signing token: %w", err)
 	}
@@ -35,12 +42,20 @@ func (maker *JWTMaker) CreateToken(id uuid.UUID, username string, email string,
 
 func (maker *JWTMaker) VerifyToken(tokenStr string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		signer := maker.keyring.Current()
+		if kid, ok := token.Header["kid"].(string); ok {
+			s, found := maker.keyring.Lookup(kid)
+			if !found {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			signer = s
+		}
+
+		if token.Method.Alg() != signer.Method().Alg() {
 			return nil, fmt.Errorf("invalid token signing method")
 		}
 
-		return []byte(maker.secretKey), nil
+		return signer.VerificationKey(), nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error parsing token: %w", err)
@@ -52,4 +67,8 @@ func (maker *JWTMaker) VerifyToken(tokenStr string) (*UserClaims, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
+
+func (maker *JWTMaker) JWKS() JWKSet {
+	return maker.keyring.JWKS()
+}
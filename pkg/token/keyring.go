@@ -0,0 +1,66 @@
+package token
+
+import "fmt"
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Keyring holds the key new tokens are signed with plus any retired keys
+// that tokens issued before a rotation may still carry in their kid header.
+type Keyring struct {
+	current Signer
+	keys    map[string]Signer
+	order   []string
+}
+
+func NewKeyring(current Signer, retired ...Signer) (*Keyring, error) {
+	if current.SigningKey() == nil {
+		return nil, fmt.Errorf("current key %s has no private key", current.KeyID())
+	}
+
+	k := &Keyring{
+		current: current,
+		keys:    make(map[string]Signer, len(retired)+1),
+	}
+	for _, s := range append([]Signer{current}, retired...) {
+		if _, ok := k.keys[s.KeyID()]; ok {
+			return nil, fmt.Errorf("duplicate key id %s in keyring", s.KeyID())
+		}
+		k.keys[s.KeyID()] = s
+		k.order = append(k.order, s.KeyID())
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) Current() Signer {
+	return k.current
+}
+
+func (k *Keyring) Lookup(kid string) (Signer, bool) {
+	s, ok := k.keys[kid]
+	return s, ok
+}
+
+// JWKS returns the public halves of every asymmetric key, current first.
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range k.order {
+		if jwk := k.keys[kid].JWK(); jwk != nil {
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return set
+}
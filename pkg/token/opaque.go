@@ -0,0 +1,31 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const opaqueTokenSize = 32
+
+// PersonalAccessTokenPrefix lets the auth middleware tell personal access
+// tokens apart from JWTs without parsing them.
+const PersonalAccessTokenPrefix = "pat_"
+
+// GenerateOpaqueToken returns a random URL-safe token. Only its hash should
+// ever be persisted.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating opaque token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the hex encoded SHA-256 digest used to look tokens up.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
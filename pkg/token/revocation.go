@@ -0,0 +1,146 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RevocationStore persists revoked token IDs, and per-user cut-offs that
+// revoke every token issued before a point in time, until their expiry.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type userCutoff struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+// RevocationList keeps revoked token IDs in memory in front of a
+// RevocationStore. Only positive lookups are cached so revocations made by
+// other instances are still picked up from the store.
+type RevocationList struct {
+	store   RevocationStore
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+	cutoffs map[uuid.UUID]userCutoff
+}
+
+func NewRevocationList(store RevocationStore) *RevocationList {
+	return &RevocationList{
+		store:   store,
+		revoked: make(map[string]time.Time),
+		cutoffs: make(map[uuid.UUID]userCutoff),
+	}
+}
+
+func (l *RevocationList) Revoke(ctx context.Context, claims *UserClaims) error {
+	if claims.RegisteredClaims.ID == "" || claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no id or expiry")
+	}
+
+	jti, expiresAt := claims.RegisteredClaims.ID, claims.ExpiresAt.Time
+	if err := l.store.RevokeToken(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.revoked[jti] = expiresAt
+	l.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser invalidates every token issued to userID up to now. maxLifetime
+// is the longest lifetime any such token can have; the cut-off is kept for
+// that long.
+func (l *RevocationList) RevokeUser(ctx context.Context, userID uuid.UUID, maxLifetime time.Duration) error {
+	// iat is truncated to the millisecond, so every token issued so far is
+	// strictly before now. Rounding up to the microsecond the store keeps
+	// makes the cached and stored cut-offs agree; a token issued later in
+	// the same millisecond is revoked as well.
+	cutoff := userCutoff{
+		issuedBefore: time.Now().Truncate(time.Microsecond).Add(time.Microsecond),
+	}
+	cutoff.expiresAt = cutoff.issuedBefore.Add(maxLifetime)
+
+	if err := l.store.RevokeUserTokens(ctx, userID, cutoff.issuedBefore, cutoff.expiresAt); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.cutoffs[userID] = cutoff
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *RevocationList) IsRevoked(ctx context.Context, claims *UserClaims) (bool, error) {
+	jti := claims.RegisteredClaims.ID
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	l.mu.RLock()
+	_, ok := l.revoked[jti]
+	cutoff, hasCutoff := l.cutoffs[claims.ID]
+	l.mu.RUnlock()
+	if ok || (hasCutoff && issuedAt.Before(cutoff.issuedBefore)) {
+		return true, nil
+	}
+
+	revoked, err := l.store.IsTokenRevoked(ctx, jti, claims.ID, issuedAt)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// Sweep drops entries whose tokens have expired from both the cache and the
+// store; an expired token is rejected by VerifyToken anyway.
+func (l *RevocationList) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	l.mu.Lock()
+	for jti, expiresAt := range l.revoked {
+		if now.After(expiresAt) {
+			delete(l.revoked, jti)
+		}
+	}
+	for userID, cutoff := range l.cutoffs {
+		if now.After(cutoff.expiresAt) {
+			delete(l.cutoffs, userID)
+		}
+	}
+	l.mu.Unlock()
+
+	_, err := l.store.DeleteExpired(ctx)
+	return err
+}
+
+// StartSweeper runs Sweep every interval until ctx is cancelled.
+func (l *RevocationList) StartSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Sweep(ctx); err != nil {
+				zap.L().Error("error sweeping revoked tokens", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore mirrors the revocation repository's queries in memory.
+type memoryStore struct {
+	tokens  map[string]time.Time
+	cutoffs map[uuid.UUID]time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{tokens: map[string]time.Time{}, cutoffs: map[uuid.UUID]time.Time{}}
+}
+
+func (s *memoryStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *memoryStore) RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore time.Time, expiresAt time.Time) error {
+	if issuedBefore.After(s.cutoffs[userID]) {
+		s.cutoffs[userID] = issuedBefore
+	}
+	return nil
+}
+
+func (s *memoryStore) IsTokenRevoked(ctx context.Context, jti string, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	_, ok := s.tokens[jti]
+	return ok || s.cutoffs[userID].After(issuedAt), nil
+}
+
+func (s *memoryStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// issue signs and verifies a fresh token for userID, so its iat has been
+// through encoding like one presented by a client.
+func issue(t *testing.T, maker *JWTMaker, userID uuid.UUID) *UserClaims {
+	t.Helper()
+	signed, _, err := maker.CreateToken(userID, "alice", "alice@example.com", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := maker.VerifyToken(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestRevokeUserCutoff(t *testing.T) {
+	ctx := context.Background()
+	maker := NewJWTMaker("revocation-test-secret")
+
+	for _, tc := range []struct {
+		name string
+		// viaStore checks from a second list that only sees the cut-off
+		// through the shared store.
+		viaStore bool
+	}{
+		{"cache", false},
+		{"store", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMemoryStore()
+			writer := NewRevocationList(store)
+			reader := writer
+			if tc.viaStore {
+				reader = NewRevocationList(store)
+			}
+			userID := uuid.New()
+
+			// Issued in the same second as the revocation, just before it.
+			before := issue(t, maker, userID)
+			if err := writer.RevokeUser(ctx, userID, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			// Decoding iat can round it down by a millisecond.
+			time.Sleep(3 * time.Millisecond)
+			after := issue(t, maker, userID)
+
+			if revoked, _ := reader.IsRevoked(ctx, before); !revoked {
+				t.Errorf("token issued at %s, just before the cut-off, is not revoked", before.IssuedAt.Time)
+			}
+			if revoked, _ := reader.IsRevoked(ctx, after); revoked {
+				t.Errorf("token issued at %s, after the cut-off, is revoked", after.IssuedAt.Time)
+			}
+		})
+	}
+}
+
+func TestIssuedAtKeepsMilliseconds(t *testing.T) {
+	maker := NewJWTMaker("revocation-test-secret")
+	// iat is decoded through a float, which can cost it a millisecond.
+	start := time.Now().Truncate(time.Millisecond).Add(-time.Millisecond)
+
+	claims := issue(t, maker, uuid.New())
+
+	if iat := claims.IssuedAt.Time; iat.Before(start) || iat.Sub(start) > 100*time.Millisecond {
+		t.Errorf("iat = %s, want the issuing time to the millisecond (started at %s)", iat, start)
+	}
+}
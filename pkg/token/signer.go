@@ -0,0 +1,165 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// Signer is a single key in a Keyring. Verify-only keys, such as retired
+// public keys, return nil from SigningKey.
+type Signer interface {
+	KeyID() string
+	Method() jwt.SigningMethod
+	SigningKey() interface{}
+	VerificationKey() interface{}
+	JWK() *JWK
+}
+
+type signer struct {
+	kid     string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+	jwk     *JWK
+}
+
+func (s *signer) KeyID() string                { return s.kid }
+func (s *signer) Method() jwt.SigningMethod    { return s.method }
+func (s *signer) SigningKey() interface{}      { return s.private }
+func (s *signer) VerificationKey() interface{} { return s.public }
+func (s *signer) JWK() *JWK                    { return s.jwk }
+
+// NewHMACSigner returns an HS256 signer. Symmetric keys are never published
+// in the JWKS document.
+func NewHMACSigner(secret []byte) Signer {
+	kid := thumbprint(fmt.Sprintf(`{"k":"%s","kty":"oct"}`, b64(secret)))
+	return &signer{
+		kid:     kid,
+		method:  jwt.SigningMethodHS256,
+		private: secret,
+		public:  secret,
+	}
+}
+
+func NewRSASigner(key *rsa.PrivateKey) (Signer, error) {
+	s, err := newRSAPublicSigner(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	s.private = key
+	return s, nil
+}
+
+func NewEd25519Signer(key ed25519.PrivateKey) Signer {
+	s := newEd25519PublicSigner(key.Public().(ed25519.PublicKey))
+	s.private = key
+	return s
+}
+
+func newRSAPublicSigner(key *rsa.PublicKey) (*signer, error) {
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("rsa key must be at least %d bits, got %d", minRSAKeyBits, key.N.BitLen())
+	}
+
+	n := b64(key.N.Bytes())
+	e := b64(big.NewInt(int64(key.E)).Bytes())
+	kid := thumbprint(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, e, n))
+
+	return &signer{
+		kid:    kid,
+		method: jwt.SigningMethodRS256,
+		public: key,
+		jwk: &JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   n,
+			E:   e,
+		},
+	}, nil
+}
+
+func newEd25519PublicSigner(key ed25519.PublicKey) *signer {
+	x := b64(key)
+	kid := thumbprint(fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, x))
+
+	return &signer{
+		kid:    kid,
+		method: jwt.SigningMethodEdDSA,
+		public: key,
+		jwk: &JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   x,
+		},
+	}
+}
+
+// LoadSignerFromPEM reads an RSA or Ed25519 key from path. Private keys can
+// sign and verify, public keys are accepted for verifying retired keys only.
+// The key ID is the RFC 7638 thumbprint of the public key.
+func LoadSignerFromPEM(path string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key file %s: %w", path, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return NewRSASigner(k)
+	case ed25519.PrivateKey:
+		return NewEd25519Signer(k), nil
+	case *rsa.PublicKey:
+		return newRSAPublicSigner(k)
+	case ed25519.PublicKey:
+		return newEd25519PublicSigner(k), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T in %s", key, path)
+	}
+}
+
+func thumbprint(canonicalJWK string) string {
+	sum := sha256.Sum256([]byte(canonicalJWK))
+	return b64(sum[:])
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
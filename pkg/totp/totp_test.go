@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of RFC 6238 Appendix B, "12345678901234567890".
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// The appendix lists 8 digit codes; with 6 digits the same value is
+// reduced to its last six.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := Code(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		ok   bool
+	}{
+		{"current step", code, now, true},
+		{"previous step", code, now.Add(period * time.Second), true},
+		{"next step", code, now.Add(-period * time.Second), true},
+		{"outside skew", code, now.Add(2 * period * time.Second), false},
+		{"surrounding spaces", " " + code + " ", now, true},
+		{"wrong code", "000000", now, false},
+		{"too short", code[:5], now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok := Validate(rfcSecret, tt.code, tt.at)
+			if ok != tt.ok {
+				t.Fatalf("Validate ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && s != step(now) {
+				t.Errorf("Validate step = %d, want %d", s, step(now))
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidSecret(t *testing.T) {
+	if _, ok := Validate("not base32!", "123456", time.Now()); ok {
+		t.Error("Validate accepted a code for an invalid secret")
+	}
+}